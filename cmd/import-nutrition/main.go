@@ -0,0 +1,45 @@
+// Command import-nutrition loads the nutrition facts of foods from a CSV file into the
+// database, replacing the foods with the same name. It takes the flags of the server,
+// such as -database-url, and is run from the root of the repository:
+//
+//	go run -tags sqlite_fts5 ./cmd/import-nutrition -csv nutrition/foods.csv
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	migrate "vilmasoftware.com/colablists/cmd"
+	"vilmasoftware.com/colablists/pkg/config"
+	"vilmasoftware.com/colablists/pkg/nutrition"
+)
+
+func main() {
+	csvPath := flag.String("csv", "./nutrition/foods.csv", "CSV file with the nutrition facts of foods")
+	// Parses the flags, including the one above
+	config.GetConfig()
+
+	result := migrate.MigrateDb()
+	if result.Error != nil {
+		if result.MigrationError != "" {
+			log.Println("Migration error: ", result.MigrationError)
+		}
+		log.Fatal(result.Error)
+	}
+	file, err := os.Open(*csvPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer file.Close()
+	foods, err := nutrition.ParseCSV(file)
+	if err != nil {
+		log.Fatalf("Failed to read %s: %v", *csvPath, err)
+	}
+	repository := &nutrition.SqlNutritionRepository{}
+	imported, err := repository.Import(foods)
+	if err != nil {
+		log.Fatal(err)
+	}
+	log.Printf("Imported %d foods from %s\n", imported, *csvPath)
+}
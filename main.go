@@ -5,12 +5,15 @@ import (
 	"crypto/sha256"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"net/mail"
 	"os"
 	"os/signal"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
@@ -18,28 +21,58 @@ import (
 	"github.com/gorilla/websocket"
 	migrate "vilmasoftware.com/colablists/cmd"
 	recovery "vilmasoftware.com/colablists/pkg"
+	"vilmasoftware.com/colablists/pkg/attachment"
 	"vilmasoftware.com/colablists/pkg/community"
 	"vilmasoftware.com/colablists/pkg/config"
+	"vilmasoftware.com/colablists/pkg/delivery"
+	"vilmasoftware.com/colablists/pkg/infra"
 	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/mealplan"
+	"vilmasoftware.com/colablists/pkg/nutrition"
+	"vilmasoftware.com/colablists/pkg/pantry"
+	"vilmasoftware.com/colablists/pkg/purchase"
 	"vilmasoftware.com/colablists/pkg/realtime"
+	"vilmasoftware.com/colablists/pkg/recipe"
+	"vilmasoftware.com/colablists/pkg/recurrence"
+	"vilmasoftware.com/colablists/pkg/reminder"
 	"vilmasoftware.com/colablists/pkg/session"
+	"vilmasoftware.com/colablists/pkg/share"
+	"vilmasoftware.com/colablists/pkg/trash"
+	"vilmasoftware.com/colablists/pkg/trip"
+	"vilmasoftware.com/colablists/pkg/units"
 	"vilmasoftware.com/colablists/pkg/user"
 	"vilmasoftware.com/colablists/pkg/views"
 )
 
 var (
-	listsRepository     list.ListsRepository       = &list.SqlListRepository{}
-	usersRepository     user.UsersRepository       = &user.SqlUsersRepository{}
-	communityRepository *community.HouseRepository = &community.HouseRepository{}
+	listsRepository     list.ListsRepository          = &list.SqlListRepository{}
+	usersRepository     user.UsersRepository          = &user.SqlUsersRepository{}
+	communityRepository *community.HouseRepository    = &community.HouseRepository{}
+	tripsRepository     trip.TripsRepository          = &trip.SqlTripsRepository{}
+	remindersRepository reminder.RemindersRepository  = &reminder.SqlRemindersRepository{}
+	purchasesRepository purchase.PurchasesRepository  = &purchase.SqlPurchasesRepository{}
+	pantryRepository    pantry.PantryRepository       = &pantry.SqlPantryRepository{}
+	recipesRepository   recipe.RecipesRepository      = &recipe.SqlRecipesRepository{}
+	mealsRepository     mealplan.MealsRepository      = &mealplan.SqlMealsRepository{}
+	nutritionRepository nutrition.NutritionRepository = &nutrition.SqlNutritionRepository{}
+	sharesRepository    share.SharesRepository        = &share.SqlSharesRepository{}
 )
 
 var (
-	liveEditor *realtime.LiveEditor = realtime.NewLiveEditor(listsRepository)
-	upgrader                        = websocket.Upgrader{
+	// Its live lists are set up in main, as the live editor depends on it
+	pantryService   *pantry.Service      = &pantry.Service{Repository: pantryRepository}
+	liveEditor      *realtime.LiveEditor = realtime.NewLiveEditor(listsRepository, usersRepository, tripsRepository, purchasesRepository, pantryService)
+	recipeService   *recipe.Service      = &recipe.Service{Live: liveEditor}
+	mealPlanService *mealplan.Service    = &mealplan.Service{Meals: mealsRepository, Recipes: recipesRepository, Lists: listsRepository, Live: liveEditor}
+	upgrader                             = websocket.Upgrader{
 		ReadBufferSize:  1024,
 		WriteBufferSize: 1024,
 	}
 	recoveryService *recovery.Recovery = &recovery.Recovery{UserRepository: usersRepository}
+	// Set up in main, as its storage depends on the config
+	attachmentService *attachment.Service
+	// Set up in main when a delivery provider is configured, nil otherwise
+	deliveryService *delivery.Service
 )
 
 func getIndexHandler(w http.ResponseWriter, r *http.Request) {
@@ -122,9 +155,23 @@ func getListsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	lists, err := listsRepository.GetAll(user.Id)
+	query, err := getListsQuery(r, user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	page, err := listsRepository.GetAll(user.Id, *query)
+	if errors.Is(err, list.ErrInvalidCursor) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	tags, err := listsRepository.FindTags(user.Id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
 
 	communities, err := communityRepository.FindMyHouses(user.Id)
@@ -132,16 +179,124 @@ func getListsHandler(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	templates, err := listsRepository.FindTemplates(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	templateId, _ := strconv.ParseInt(r.URL.Query().Get("templateId"), 10, 64)
+	filters := views.ListsFilters{Query: *query, Tags: tags, UserId: user.Id, Sorts: list.Sorts}
+	if page.NextCursor != "" {
+		next := r.URL.Query()
+		next.Set("cursor", page.NextCursor)
+		filters.NextPage = "/lists?" + next.Encode()
+	}
 	views.Templates.RenderLists(w, &views.ListsArgs{
-		Lists: lists,
+		Lists:     page.Lists,
+		Filters:   filters,
+		Templates: templates,
 		Form: views.ListCreationForm{
 			Communities:      communities,
 			DefaultCommunity: community.GetDefault(communities),
+			Templates:        templates,
+			TemplateId:       templateId,
 		},
 		New: r.URL.Query().Has("new"),
 	})
 }
 
+func getSearchHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	query := r.URL.Query().Get("q")
+	results, err := listsRepository.Search(user.Id, query, 50)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views.Templates.RenderSearch(w, &views.SearchArgs{Query: query, Results: results})
+}
+
+// Lists shown on each page of the lists page
+const listsPageSize = 20
+
+// getListsQuery reads the filters, sort and page of the lists page, where creator=me
+// stands for the logged user.
+func getListsQuery(r *http.Request, userId int64) (*list.ListsQuery, error) {
+	values := r.URL.Query()
+	query := &list.ListsQuery{
+		Tag:    values.Get("tag"),
+		Status: values.Get("status"),
+		Sort:   values.Get("sort"),
+		Cursor: values.Get("cursor"),
+		Limit:  listsPageSize,
+	}
+	if value := values.Get("community"); value != "" {
+		communityId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("community should be integer")
+		}
+		query.CommunityId = &communityId
+	}
+	if value := values.Get("creator"); value == "me" {
+		query.CreatorId = &userId
+	} else if value != "" {
+		creatorId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("creator should be integer or me")
+		}
+		query.CreatorId = &creatorId
+	}
+	if query.Status != "" && query.Status != list.StatusOpen && query.Status != list.StatusDone {
+		return nil, list.ErrInvalidStatus
+	}
+	if query.Sort != "" && !slices.Contains(list.Sorts, query.Sort) {
+		return nil, list.ErrInvalidSort
+	}
+	return query, nil
+}
+
+func getMyItemsHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	page, err := listsRepository.GetAll(user.Id, list.ListsQuery{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	saved, err := listsRepository.FindAssignedItems(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	savedByList := make(map[int64][]list.AssignedItem)
+	for _, item := range saved {
+		savedByList[item.ListId] = append(savedByList[item.ListId], item)
+	}
+	items := make([]list.AssignedItem, 0, len(saved))
+	for _, l := range page.Lists {
+		// Unsaved changes of lists being edited are shown too
+		if current, _ := liveEditor.Snapshot(l.Id); current != nil {
+			items = append(items, list.ItemsAssignedTo([]list.List{*current.List}, user.Id)...)
+			continue
+		}
+		items = append(items, savedByList[l.Id]...)
+	}
+	views.Templates.RenderMyItems(w, &views.MyItemsArgs{Items: items})
+}
+
 func redirectIfNotLoggedIn(w http.ResponseWriter, r *http.Request) bool {
 	_, err := session.GetUserFromSession(r)
 	if err != nil {
@@ -172,15 +327,48 @@ func getListDetailHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	role := list.RoleOf(user.Id)
+	if role == "" {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return
+	}
 	listArgs := &views.ListArgs{
-		List:    *views.NewListUi(&list, user),
-		Editing: r.URL.Query().Has("edit"),
-		IsDirty: false,
+		List:     *views.NewListUi(&list, user),
+		Editing:  r.URL.Query().Has("edit") && role.CanEdit(),
+		IsDirty:  false,
+		Delivery: deliveryService != nil,
+		Role:     role,
 	}
-	list2 := liveEditor.GetCurrentListState(int64(id))
-	if list2 != nil {
-		listArgs.List = *list2.Ui
-		listArgs.IsDirty = list2.Dirty
+	listArgs.Trips, err = tripsRepository.FindByList(int64(id))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	reminders, err := remindersRepository.GetPreference(int64(id), user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	listArgs.Reminders = &reminders
+	listArgs.Occurrences, err = listsRepository.FindArchivedOccurrences(list.Series())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	listArgs.Communities, err = communityRepository.FindMyHouses(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if role.CanEdit() {
+		if listArgs.Shares, err = sharesRepository.FindActive(int64(id)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	if current, dirty := liveEditor.Snapshot(int64(id)); current != nil {
+		listArgs.List = *current
+		listArgs.IsDirty = dirty
 	}
 	views.Templates.RenderList(w, listArgs)
 }
@@ -212,6 +400,31 @@ func getUsersHandler(w http.ResponseWriter, r *http.Request) {
 	}
 }
 
+func getSuggestionsHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	limit := 10
+	if value := r.URL.Query().Get("limit"); value != "" {
+		limit, err = strconv.Atoi(value)
+		if err != nil || limit <= 0 || limit > 50 {
+			http.Error(w, "limit should be between 1 and 50", http.StatusBadRequest)
+			return
+		}
+	}
+	suggestions, err := listsRepository.Suggest(user.Id, r.URL.Query().Get("q"), limit)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(suggestions); err != nil {
+		log.Println("Error encoding suggestions", err)
+	}
+}
+
 func postListsHandler(w http.ResponseWriter, r *http.Request) {
 	title := r.FormValue("title")
 	description := r.FormValue("description")
@@ -228,6 +441,30 @@ func postListsHandler(w http.ResponseWriter, r *http.Request) {
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 	}
+	if templateIdString := r.FormValue("templateId"); templateIdString != "" {
+		templateId, err := strconv.ParseInt(templateIdString, 10, 64)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !canCopyList(w, user.Id, templateId) {
+			return
+		}
+		created, err := listsRepository.Duplicate(&list.DuplicateParams{
+			SourceId:    templateId,
+			Title:       title,
+			Description: description,
+			CreatorId:   user.Id,
+			CommunityId: communityId,
+			CopyOptions: list.CopyOptions{Uncheck: true},
+		})
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		http.Redirect(w, r, fmt.Sprintf("/lists/%d", created.Id), http.StatusSeeOther)
+		return
+	}
 	_, err = listsRepository.Create(&list.ListCreationParams{
 		Title:       title,
 		Description: description,
@@ -240,350 +477,2148 @@ func postListsHandler(w http.ResponseWriter, r *http.Request) {
 	http.Redirect(w, r, "/lists", http.StatusSeeOther)
 }
 
-func putListSaveHandler(w http.ResponseWriter, r *http.Request) {
-	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+// canCopyList tells whether the user can copy the list: its members can, as can
+// everyone the list is shared with as a template.
+func canCopyList(w http.ResponseWriter, userId int64, listId int64) bool {
+	l, err := listsRepository.Get(listId)
 	if err != nil {
-		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return false
 	}
-	found := liveEditor.GetCurrentListUi(listId)
-	if found == nil {
-		http.Error(w, "List not found", http.StatusNotFound)
-		return
+	if l.IsMember(userId) {
+		return true
 	}
-	list, err := listsRepository.Update(found.List)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+	if l.IsTemplate {
+		templates, err := listsRepository.FindTemplates(userId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return false
+		}
+		for _, template := range templates {
+			if template.Id == listId {
+				return true
+			}
+		}
 	}
-	user, err := session.GetUserFromSession(r)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
+	http.Error(w, "You cannot copy this list", http.StatusForbidden)
+	return false
+}
+
+// canEditList tells whether the user can change the list, which viewers and commenters can't.
+func canEditList(w http.ResponseWriter, l *list.List, userId int64) bool {
+	if !l.IsMember(userId) {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return false
 	}
-	// TODO: send changes to all users
-	views.Templates.RenderSaveList(w, &views.ListArgs{List: *views.NewListUi(list, user), IsDirty: false})
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+	if !l.RoleOf(userId).CanEdit() {
+		http.Error(w, "Only editors can change this list", http.StatusForbidden)
+		return false
 	}
+	return true
 }
 
-type UpdateListParams struct {
-	Title       *string   `json:"title"`
-	Description *string   `json:"description"`
-	Members     *[]string `json:"members"`
+func postListDuplicateHandler(w http.ResponseWriter, r *http.Request) {
+	copyListFromRequest(w, r, false)
 }
 
-func putListHandler(w http.ResponseWriter, r *http.Request) {
+func postListTemplateHandler(w http.ResponseWriter, r *http.Request) {
+	copyListFromRequest(w, r, true)
+}
+
+// copyListFromRequest duplicates the list in the path, or saves it as a template.
+func copyListFromRequest(w http.ResponseWriter, r *http.Request, asTemplate bool) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
 	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
 	if err != nil {
 		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
 	}
-	list, err := listsRepository.Get(listId)
+	user, err := session.GetUserFromSession(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
-
-	var params UpdateListParams
-	json.NewDecoder(r.Body).Decode(&params)
-	if params.Title != nil {
-		list.Title = *params.Title
+	if !canCopyList(w, user.Id, listId) {
+		return
 	}
-	if params.Description != nil {
-		list.Description = *params.Description
+	source, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
-	if params.Members != nil {
-		list.Colaborators = []user.User{}
-		for _, colaborator := range *params.Members {
-			colaboratorId, err := strconv.Atoi(colaborator)
+	params := &list.DuplicateParams{
+		SourceId:    listId,
+		Title:       source.Title,
+		Description: source.Description,
+		CreatorId:   user.Id,
+		IsTemplate:  asTemplate,
+		CopyOptions: list.CopyOptions{
+			Uncheck:         asTemplate || r.FormValue("uncheck") != "",
+			ResetQuantities: r.FormValue("resetQuantities") != "",
+		},
+	}
+	if asTemplate {
+		if communityIdString := r.FormValue("communityId"); communityIdString != "" {
+			communityId, err := strconv.ParseInt(communityIdString, 10, 64)
 			if err != nil {
 				http.Error(w, err.Error(), http.StatusBadRequest)
-			}
-			user, err := usersRepository.Get(int64(colaboratorId))
-			if err != nil {
-				http.Error(w, err.Error(), http.StatusInternalServerError)
 				return
 			}
-			list.Colaborators = append(list.Colaborators, user)
+			params.CommunityId = &communityId
+		}
+	} else {
+		params.Title = "Copy of " + source.Title
+		params.KeepColaborators = true
+		params.KeepAssignees = true
+		if source.Community != nil {
+			params.CommunityId = &source.Community.CommunityId
 		}
 	}
-	listv, err := listsRepository.Update(&list)
+	created, err := listsRepository.Duplicate(params)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-
-	listState := liveEditor.GetCurrentListState(listId)
-	if listState != nil {
-		listState.Ui.List = listv
-	}
-
-	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", created.Id))
 }
 
-func getListEditorHandler(w http.ResponseWriter, r *http.Request) {
-	user, err := session.GetUserFromSession(r)
+// getMergePlan plans merging the list given in the source form value into the one in the path.
+// The user must be an editor of the target and the creator of the source, as it goes to the trash.
+func getMergePlan(w http.ResponseWriter, r *http.Request, userId int64) (*list.MergePlan, bool) {
+	targetId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return nil, false
 	}
-	conn, err := upgrader.Upgrade(w, r, nil)
+	sourceId, err := strconv.ParseInt(r.FormValue("source"), 10, 64)
+	if err != nil {
+		http.Error(w, "source should be integer", http.StatusBadRequest)
+		return nil, false
+	}
+	if sourceId == targetId {
+		http.Error(w, "A list cannot be merged into itself", http.StatusBadRequest)
+		return nil, false
+	}
+	target, err := listsRepository.Get(targetId)
 	if err != nil {
-		// Return Internal Error
 		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, false
 	}
-	listId, err := strconv.Atoi(r.URL.Query().Get("listId"))
+	if !canEditList(w, &target, userId) {
+		return nil, false
+	}
+	source, err := listsRepository.Get(sourceId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
 	}
-	liveEditor.SetupList(int64(listId), user, conn)
-}
-
-func getSignupHandler(w http.ResponseWriter, r *http.Request) {
-	views.Templates.RenderSignup(w, &views.SignupArgs{FormError: r.URL.Query().Get("formError")})
+	if source.Creator.Id != userId || source.DeletedAt != nil {
+		http.Error(w, "Only lists you created can be merged into another", http.StatusForbidden)
+		return nil, false
+	}
+	return list.PlanMerge(&target, &source), true
 }
 
-func getCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+func getListMergeHandler(w http.ResponseWriter, r *http.Request) {
 	if redirectIfNotLoggedIn(w, r) {
 		return
 	}
-	comunityPageArgs := &views.CommunitiesArgs{}
-	query, err := getCommunitiesQuery(r)
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
 		return
 	}
-	comunityPageArgs.Query = *query
-	if query.SelectedId > 0 && query.EditingId > 0 {
-		http.Error(w, "Cannot select and edit at the same time", http.StatusBadRequest)
-	} else if query.SelectedId > 0 || query.EditingId > 0 {
-		comunityPageArgs.SelectedCommunity, err = communityRepository.Get(query.SelectedId + query.EditingId)
-		if err != nil {
-			http.Error(w, err.Error(), http.StatusBadRequest)
-		}
-	}
 	user, err := session.GetUserFromSession(r)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	comunityPageArgs.Communities, err = communityRepository.FindMyHouses(user.Id)
+	args := &views.MergeArgs{}
+	if r.FormValue("source") != "" {
+		plan, ok := getMergePlan(w, r, user.Id)
+		if !ok {
+			return
+		}
+		args.Plan = plan
+	}
+	mine, err := listsRepository.GetAll(user.Id, list.ListsQuery{CreatorId: &user.Id})
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	views.Templates.RenderCommunities(w, comunityPageArgs)
-}
-
-func getCommunitiesQuery(r *http.Request) (*views.CommunitiesQuery, error) {
-	var err error
-	result := &views.CommunitiesQuery{}
-
-	selectedIdString := r.URL.Query().Get("selectedId")
-	if selectedIdString != "" {
-		result.SelectedId, err = strconv.ParseInt(selectedIdString, 10, 64)
-		if err != nil {
-			return nil, err
+	for _, l := range mine.Lists {
+		if l.Id != listId {
+			args.Sources = append(args.Sources, l)
 		}
 	}
-	newString := r.URL.Query().Get("new")
-	if newString != "" {
-		result.New, err = strconv.ParseBool(newString)
+	if args.Plan == nil {
+		target, err := listsRepository.Get(listId)
 		if err != nil {
-			return nil, err
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
 		}
-	}
-
-	editingIdString := r.URL.Query().Get("editingId")
-	if editingIdString != "" {
-		result.EditingId, err = strconv.ParseInt(editingIdString, 10, 64)
-		if err != nil {
-			return nil, err
+		if !canEditList(w, &target, user.Id) {
+			return
 		}
+		args.Target = target
+	} else {
+		args.Target = *args.Plan.Target
 	}
-	return result, nil
+	views.Templates.RenderMerge(w, args)
 }
 
-var allconns []*websocket.Conn = make([]*websocket.Conn, 0)
-
-func getHotReloadHandler(w http.ResponseWriter, r *http.Request) {
-	conn, err := upgrader.Upgrade(w, r, nil)
+func postListMergeHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
 	if err != nil {
-		// Return Internal Error
-		http.Error(w, err.Error(), http.StatusInternalServerError)
+		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	allconns = append(allconns, conn)
-	go handleHotReload(conn)
+	plan, ok := getMergePlan(w, r, user.Id)
+	if !ok {
+		return
+	}
+	merged, err := listsRepository.Merge(plan, user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	liveEditor.Reload(merged.Id)
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", merged.Id))
+}
+
+func putListSaveHandler(w http.ResponseWriter, r *http.Request) {
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+	}
+	found, _ := liveEditor.Snapshot(listId)
+	if found == nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !found.List.RoleOf(user.Id).CanEdit() {
+		http.Error(w, "Only editors can save this list", http.StatusForbidden)
+		return
+	}
+	list, err := liveEditor.Save(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if list == nil {
+		http.Error(w, "List not found", http.StatusNotFound)
+		return
+	}
+	// TODO: send changes to all users
+	views.Templates.RenderSaveList(w, &views.ListArgs{List: *views.NewListUi(list, user), IsDirty: false})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+type UpdateListParams struct {
+	Title       *string   `json:"title"`
+	Description *string   `json:"description"`
+	Members     *[]string `json:"members"`
+	Budget      *string   `json:"budget"`
+	Currency    *string   `json:"currency"`
+	DueAt       *string   `json:"dueAt"`
+	// Rule of a recurring list, empty to stop it from recurring
+	Recurrence     *string `json:"recurrence"`
+	RecurrenceMode *string `json:"recurrenceMode"`
+	// Comma separated
+	Tags *string `json:"tags"`
+	// Role of each member, in the same order, editor when missing
+	Roles *[]string `json:"roles"`
+}
+
+func (params *UpdateListParams) applyTags(l *list.List) {
+	if params.Tags != nil {
+		l.Tags = list.NormalizeTags(*params.Tags)
+	}
+}
+
+func (params *UpdateListParams) applyBudget(l *list.List) error {
+	if params.Budget != nil {
+		if *params.Budget == "" {
+			l.Budget = nil
+		} else {
+			budget, err := strconv.ParseFloat(*params.Budget, 64)
+			if err != nil || budget < 0 {
+				return fmt.Errorf("budget should be a positive number")
+			}
+			l.Budget = &budget
+		}
+	}
+	if params.Currency != nil {
+		currency, ok := list.NormalizeCurrency(*params.Currency)
+		if !ok {
+			return fmt.Errorf("currency should be a three letter code like BRL")
+		}
+		l.Currency = currency
+	}
+	return nil
+}
+
+// applyDueAt reads the due date from a datetime-local input, in the server time zone.
+func (params *UpdateListParams) applyDueAt(l *list.List) error {
+	if params.DueAt == nil {
+		return nil
+	}
+	if *params.DueAt == "" {
+		l.DueAt = nil
+		return nil
+	}
+	dueAt, err := time.ParseInLocation("2006-01-02T15:04", *params.DueAt, time.Local)
+	if err != nil {
+		return fmt.Errorf("due date should be like 2006-01-02T15:04")
+	}
+	dueAt = dueAt.UTC()
+	l.DueAt = &dueAt
+	return nil
+}
+
+func (params *UpdateListParams) applyRecurrence(l *list.List) error {
+	if params.RecurrenceMode != nil {
+		if *params.RecurrenceMode != list.RecurrenceReset && *params.RecurrenceMode != list.RecurrenceSpawn {
+			return fmt.Errorf("recurrence mode should be %s or %s", list.RecurrenceReset, list.RecurrenceSpawn)
+		}
+		l.RecurrenceMode = *params.RecurrenceMode
+	}
+	if params.Recurrence == nil || *params.Recurrence == l.Recurrence {
+		return nil
+	}
+	rule := strings.TrimSpace(*params.Recurrence)
+	if rule == "" {
+		l.Recurrence = ""
+		l.NextOccurrenceAt = nil
+		return nil
+	}
+	schedule, err := recurrence.Parse(rule)
+	if err != nil {
+		return err
+	}
+	next := schedule.Next(time.Now())
+	if next.IsZero() {
+		return fmt.Errorf("recurrence %q never happens", rule)
+	}
+	next = next.UTC()
+	l.Recurrence = rule
+	l.NextOccurrenceAt = &next
+	return nil
+}
+
+func putListHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	currentUser, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	if !canEditList(w, &l, currentUser.Id) {
+		return
+	}
+
+	var params UpdateListParams
+	json.NewDecoder(r.Body).Decode(&params)
+	if (params.Members != nil || params.Roles != nil) && !l.RoleOf(currentUser.Id).CanManage() {
+		http.Error(w, "Only the owner of the list can change its colaborators", http.StatusForbidden)
+		return
+	}
+	if params.Title != nil {
+		l.Title = *params.Title
+	}
+	if params.Description != nil {
+		l.Description = *params.Description
+	}
+	params.applyTags(&l)
+	if err := params.applyBudget(&l); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := params.applyDueAt(&l); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := params.applyRecurrence(&l); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if params.Members != nil {
+		l.Colaborators = []user.User{}
+		for i, colaborator := range *params.Members {
+			colaboratorId, err := strconv.Atoi(colaborator)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+			}
+			user, err := usersRepository.Get(int64(colaboratorId))
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			l.Colaborators = append(l.Colaborators, user)
+			if params.Roles != nil && i < len(*params.Roles) {
+				role, err := list.ParseRole((*params.Roles)[i])
+				if err != nil {
+					http.Error(w, err.Error(), http.StatusBadRequest)
+					return
+				}
+				l.SetRole(user.Id, role)
+			}
+		}
+	}
+	listv, err := listsRepository.Update(&l)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	liveEditor.Replace(listId, listv)
+
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+}
+
+// postListOwnerHandler makes the colaborator in the userId form value the owner of the list.
+func postListOwnerHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
+	}
+	ownerId, err := strconv.ParseInt(r.FormValue("userId"), 10, 64)
+	if err != nil {
+		http.Error(w, "userId should be integer", http.StatusBadRequest)
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !l.RoleOf(user.Id).CanManage() {
+		http.Error(w, "Only the owner of the list can transfer it", http.StatusForbidden)
+		return
+	}
+	var transferErr error
+	err = liveEditor.Edit(listId, func(target *list.List) bool {
+		transferErr = target.TransferOwnership(ownerId)
+		return transferErr == nil
+	})
+	if transferErr != nil {
+		http.Error(w, transferErr.Error(), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	// Saves the unsaved changes of the editors along
+	if _, err := liveEditor.Save(listId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+}
+
+func getListEditorHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	listId, err := strconv.ParseInt(r.URL.Query().Get("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId should be integer", http.StatusBadRequest)
+		return
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if l.RoleOf(user.Id) == "" {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Return Internal Error
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	liveEditor.SetupList(listId, user, conn)
+}
+
+// getActiveShare finds the share link of the token, which must still work.
+func getActiveShare(w http.ResponseWriter, token string) (*share.Share, bool) {
+	found, err := sharesRepository.FindByToken(token)
+	if errors.Is(err, share.ErrNotFound) || (err == nil && !found.IsActive(time.Now())) {
+		http.Error(w, "This link does not exist, has expired or was revoked, or its list was deleted", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return &found, true
+}
+
+// getSharedListHandler shows the list of a share link to anyone with it, without a session.
+func getSharedListHandler(w http.ResponseWriter, r *http.Request) {
+	found, ok := getActiveShare(w, r.PathValue("token"))
+	if !ok {
+		return
+	}
+	l, err := listsRepository.Get(found.ListId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	args := &views.SharedListArgs{List: *views.NewListUi(&l, found.Guest()), Share: *found}
+	if current, _ := liveEditor.Snapshot(found.ListId); current != nil {
+		args.List = *current
+	}
+	views.Templates.RenderSharedList(w, args)
+}
+
+func getSharedEditorHandler(w http.ResponseWriter, r *http.Request) {
+	found, ok := getActiveShare(w, r.URL.Query().Get("token"))
+	if !ok {
+		return
+	}
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := liveEditor.SetupShare(found, conn); err != nil {
+		log.Println("Error following shared list", found.ListId, err)
+		conn.Close()
+	}
+}
+
+// postListShareHandler creates a share link of the list, which may let whoever has it check items
+// off and expire at the expiresAt form value.
+func postListShareHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !canEditList(w, &l, user.Id) {
+		return
+	}
+	created := &share.Share{ListId: listId, CanCheck: r.FormValue("canCheck") != "", CreatorId: user.Id}
+	if value := r.FormValue("expiresAt"); value != "" {
+		expiresAt, err := time.ParseInLocation("2006-01-02T15:04", value, time.Local)
+		if err != nil {
+			http.Error(w, "expiry date should be like 2006-01-02T15:04", http.StatusBadRequest)
+			return
+		}
+		if !expiresAt.After(time.Now()) {
+			http.Error(w, "The link would expire before being used", http.StatusBadRequest)
+			return
+		}
+		expiresAt = expiresAt.UTC()
+		created.ExpiresAt = &expiresAt
+	}
+	if err := sharesRepository.Create(created); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+}
+
+// deleteListShareHandler revokes a share link, disconnecting who is following the list through it.
+func deleteListShareHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
+	}
+	shareId, err := strconv.ParseInt(r.PathValue("shareId"), 10, 64)
+	if err != nil {
+		http.Error(w, "shareId path value should be integer", http.StatusBadRequest)
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !canEditList(w, &l, user.Id) {
+		return
+	}
+	if err := sharesRepository.Revoke(listId, shareId); errors.Is(err, share.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	liveEditor.Unshare(listId, shareId)
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+}
+
+func getSignupHandler(w http.ResponseWriter, r *http.Request) {
+	views.Templates.RenderSignup(w, &views.SignupArgs{FormError: r.URL.Query().Get("formError")})
+}
+
+func getCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	comunityPageArgs := &views.CommunitiesArgs{}
+	query, err := getCommunitiesQuery(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	comunityPageArgs.Query = *query
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if query.SelectedId > 0 && query.EditingId > 0 {
+		http.Error(w, "Cannot select and edit at the same time", http.StatusBadRequest)
+		return
+	} else if query.SelectedId > 0 || query.EditingId > 0 {
+		comunityPageArgs.SelectedCommunity, err = communityRepository.Get(query.SelectedId + query.EditingId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if !comunityPageArgs.SelectedCommunity.IsMember(user.Id) {
+			http.Error(w, "You are not a member of this community", http.StatusForbidden)
+			return
+		}
+		comunityPageArgs.Trips, err = tripsRepository.FindByCommunity(query.SelectedId + query.EditingId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	comunityPageArgs.Communities, err = communityRepository.FindMyHouses(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views.Templates.RenderCommunities(w, comunityPageArgs)
+}
+
+func getCommunitiesQuery(r *http.Request) (*views.CommunitiesQuery, error) {
+	var err error
+	result := &views.CommunitiesQuery{}
+
+	selectedIdString := r.URL.Query().Get("selectedId")
+	if selectedIdString != "" {
+		result.SelectedId, err = strconv.ParseInt(selectedIdString, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	newString := r.URL.Query().Get("new")
+	if newString != "" {
+		result.New, err = strconv.ParseBool(newString)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	editingIdString := r.URL.Query().Get("editingId")
+	if editingIdString != "" {
+		result.EditingId, err = strconv.ParseInt(editingIdString, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+var allconns []*websocket.Conn = make([]*websocket.Conn, 0)
+
+func getHotReloadHandler(w http.ResponseWriter, r *http.Request) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// Return Internal Error
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	allconns = append(allconns, conn)
+	go handleHotReload(conn)
+}
+
+type HotReloadMessage struct {
+	ServerRunId string `json:"serverRunId"`
+}
+
+var ServerRunID string
+
+func removeHotReloadCon(conn *websocket.Conn) {
+	for i, c := range allconns {
+		if c == conn {
+			allconns = append(allconns[:i], allconns[i+1:]...)
+			return
+		}
+	}
+}
+
+func handleHotReload(conn *websocket.Conn) {
+	defer conn.Close()
+	for {
+		hotReloadMessage := &HotReloadMessage{}
+		err := conn.ReadJSON(hotReloadMessage)
+		if err != nil {
+			removeHotReloadCon(conn)
+			return
+		}
+		conn.WriteJSON(&HotReloadMessage{ServerRunId: ServerRunID})
+	}
+}
+
+func postCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	community := &community.Community{}
+	mountCommunityFromRequest(r, user, community)
+	community.CreatedBy = user
+	_, err = communityRepository.Save(community, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", "/communities")
+}
+
+type CommunityCreationParams struct {
+	CommunityName string   `json:"communityName"`
+	MemberIds     []string `json:"members"`
+}
+
+func mountCommunityFromRequest(r *http.Request, u *user.User, c *community.Community) error {
+	params := &CommunityCreationParams{}
+	err := json.NewDecoder(r.Body).Decode(params)
+	if err != nil {
+		return err
+	}
+	c.CommunityName = params.CommunityName
+	c.CreatedBy = u
+	c.Members = make([]*community.Member, 0)
+	c.Default = false
+	for _, memberId := range params.MemberIds {
+		intMemberId, err := strconv.Atoi(memberId)
+		if err != nil {
+			return err
+		}
+		member, err := usersRepository.Get(int64(intMemberId))
+		if err != nil {
+			return err
+		}
+		c.Members = append(c.Members, &community.Member{User: member})
+	}
+	return nil
+}
+
+func putCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	community := &community.Community{}
+	mountCommunityFromRequest(r, user, community)
+	community.UpdatedAt = time.Now()
+	communityId, err := strconv.Atoi(r.PathValue("communityId"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	community.CommunityId = int64(communityId)
+	_, err = communityRepository.Save(community, &user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities?selectedId=%d", community.CommunityId))
+}
+
+func deleteCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	communityId, err := strconv.ParseInt(r.PathValue("communityId"), 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	err = communityRepository.Delete(communityId, user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", "/communities")
+}
+
+func getPasswordRecoveryHandler(w http.ResponseWriter, r *http.Request) {
+	views.Templates.RenderPasswordRecovery(w, &views.PasswordRecoveryArgs{})
+}
+
+func postPasswordRecoveryHandler(w http.ResponseWriter, r *http.Request) {
+	password := r.FormValue("password")
+	token := r.FormValue("token")
+	if err := recoveryService.RecoverPassword(password, token); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func getPasswordRecoveryRequestHandler(w http.ResponseWriter, r *http.Request) {
+	views.Templates.RenderPasswordRecoveryRequest(w, &views.PasswordRecoveryRequestArgs{})
+}
+
+func postPasswordRecoveryRequestHandler(w http.ResponseWriter, r *http.Request) {
+	email := r.FormValue("email")
+	if _, err := mail.ParseAddress(email); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := recoveryService.CreatePasswordRecoveryRequest(email); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	http.Redirect(w, r, "/login", http.StatusSeeOther)
+}
+
+func deleteListHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	lisetId := r.PathValue("listId")
+	id, err := strconv.ParseInt(lisetId, 10, 64)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	err = listsRepository.Delete(id, user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", "/lists")
+}
+
+const (
+	// Purchases older than this are left out of the reports
+	purchaseHistoryPeriod = 365 * 24 * time.Hour
+	// Items and recent purchases shown in the reports
+	purchaseStatsSize = 20
+)
+
+// getPurchasesHandler reports what the user bought, or the community given in the query.
+func getPurchasesHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	since := time.Now().Add(-purchaseHistoryPeriod)
+	args := &views.PurchasesArgs{}
+	var purchases []purchase.Purchase
+	if communityIdString := r.URL.Query().Get("community"); communityIdString != "" {
+		communityId, err := strconv.ParseInt(communityIdString, 10, 64)
+		if err != nil {
+			http.Error(w, "community should be integer", http.StatusBadRequest)
+			return
+		}
+		args.Community, err = communityRepository.Get(communityId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if !args.Community.IsMember(user.Id) {
+			http.Error(w, "You are not a member of this community", http.StatusForbidden)
+			return
+		}
+		purchases, err = purchasesRepository.FindByCommunity(communityId, since)
+	} else {
+		purchases, err = purchasesRepository.FindByBuyer(user.Id, since)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	args.Stats = purchase.Summarize(purchases, purchaseStatsSize)
+	args.Recent = purchases[:min(len(purchases), purchaseStatsSize)]
+	views.Templates.RenderPurchases(w, args)
+}
+
+// getCommunityOfMember returns the community in the path if the user is a member of it.
+func getCommunityOfMember(w http.ResponseWriter, r *http.Request, userId int64) (*community.Community, bool) {
+	communityId, err := strconv.ParseInt(r.PathValue("communityId"), 10, 64)
+	if err != nil {
+		http.Error(w, "communityId path value should be integer", http.StatusBadRequest)
+		return nil, false
+	}
+	comm, err := communityRepository.Get(communityId)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Community not found", http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !comm.IsMember(userId) {
+		http.Error(w, "You are not a member of this community", http.StatusForbidden)
+		return nil, false
+	}
+	return comm, true
+}
+
+func getPantryHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	p, err := pantryRepository.Get(comm.CommunityId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	lists, err := listsRepository.GetAll(user.Id, list.ListsQuery{CommunityId: &comm.CommunityId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views.Templates.RenderPantry(w, &views.PantryArgs{Community: comm, Pantry: p, Lists: lists.Lists, Units: units.Catalogue})
+}
+
+// applyPantryItemForm sets the fields of the item sent in the form, leaving the missing ones as they are.
+func applyPantryItemForm(r *http.Request, item *pantry.Item) error {
+	if description := strings.TrimSpace(r.FormValue("description")); description != "" {
+		item.Description = description
+	}
+	if r.Form.Has("unit") {
+		unit, ok := units.Normalize(r.FormValue("unit"))
+		if !ok {
+			return fmt.Errorf("unknown unit %q", r.FormValue("unit"))
+		}
+		item.Unit = unit
+	}
+	for field, value := range map[string]*float64{"quantity": &item.Quantity, "minimum": &item.Minimum} {
+		if r.FormValue(field) == "" {
+			continue
+		}
+		amount, err := units.ParseAmount(r.FormValue(field))
+		if err != nil {
+			return err
+		}
+		*value = amount
+	}
+	if item.Description == "" {
+		return errors.New("description is required")
+	}
+	return nil
+}
+
+// postPantryItemHandler adds an item to the pantry, or changes the one with the same description.
+func postPantryItemHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	item, err := pantryRepository.FindItem(comm.CommunityId, r.FormValue("description"))
+	if errors.Is(err, pantry.ErrNotFound) {
+		item = pantry.Item{CommunityId: comm.CommunityId}
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := applyPantryItemForm(r, &item); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := pantryService.Save(&item); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/pantry", comm.CommunityId))
+}
+
+// getPantryItemOfMember returns the item in the path if it is in the pantry of a community of the user.
+func getPantryItemOfMember(w http.ResponseWriter, r *http.Request) (*pantry.Item, bool) {
+	if redirectIfNotLoggedIn(w, r) {
+		return nil, false
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return nil, false
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return nil, false
+	}
+	itemId, err := strconv.ParseInt(r.PathValue("itemId"), 10, 64)
+	if err != nil {
+		http.Error(w, "itemId path value should be integer", http.StatusBadRequest)
+		return nil, false
+	}
+	item, err := pantryRepository.GetItem(itemId)
+	if errors.Is(err, pantry.ErrNotFound) || item.CommunityId != comm.CommunityId {
+		http.Error(w, pantry.ErrNotFound.Error(), http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return &item, true
+}
+
+func putPantryItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := getPantryItemOfMember(w, r)
+	if !ok {
+		return
+	}
+	if err := applyPantryItemForm(r, item); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := pantryService.Save(item); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/pantry", item.CommunityId))
+}
+
+// postPantryItemUseHandler takes from the stock what was used, one unit of the item when not told.
+func postPantryItemUseHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := getPantryItemOfMember(w, r)
+	if !ok {
+		return
+	}
+	quantity := 1.0
+	if value := r.FormValue("quantity"); value != "" {
+		var err error
+		quantity, err = units.ParseAmount(value)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := pantryService.Use(item, quantity); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/pantry", item.CommunityId))
+}
+
+func deletePantryItemHandler(w http.ResponseWriter, r *http.Request) {
+	item, ok := getPantryItemOfMember(w, r)
+	if !ok {
+		return
+	}
+	if err := pantryRepository.DeleteItem(item.Id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/pantry", item.CommunityId))
+}
+
+// putPantryHandler designates the list of the community the items running low are added to.
+func putPantryHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	var listId *int64
+	if value := r.FormValue("shoppingListId"); value != "" {
+		id, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "shoppingListId should be integer", http.StatusBadRequest)
+			return
+		}
+		l, err := listsRepository.Get(id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if l.Community == nil || l.Community.CommunityId != comm.CommunityId || l.DeletedAt != nil {
+			http.Error(w, "The shopping list must be a list of the community", http.StatusBadRequest)
+			return
+		}
+		if !l.RoleOf(user.Id).CanEdit() {
+			http.Error(w, "Only editors can change this list", http.StatusForbidden)
+			return
+		}
+		listId = &id
+	}
+	if err := pantryService.SetShoppingList(comm.CommunityId, listId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/pantry", comm.CommunityId))
+}
+
+func getRecipesHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	recipes, err := recipesRepository.FindVisible(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	communities, err := communityRepository.FindMyHouses(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	views.Templates.RenderRecipes(w, &views.RecipesArgs{Recipes: recipes, Communities: communities})
+}
+
+// getVisibleRecipe returns the recipe in the path if the user created it or is a member of its community.
+func getVisibleRecipe(w http.ResponseWriter, r *http.Request, userId int64) (*recipe.Recipe, bool) {
+	recipeId, err := strconv.ParseInt(r.PathValue("recipeId"), 10, 64)
+	if err != nil {
+		http.Error(w, "recipeId path value should be integer", http.StatusBadRequest)
+		return nil, false
+	}
+	found, err := recipesRepository.Get(recipeId)
+	if errors.Is(err, recipe.ErrNotFound) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return nil, false
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if found.Creator.Id == userId {
+		return &found, true
+	}
+	if found.CommunityId != nil {
+		comm, err := communityRepository.Get(*found.CommunityId)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return nil, false
+		}
+		if comm != nil && comm.IsMember(userId) {
+			return &found, true
+		}
+	}
+	http.Error(w, recipe.ErrNotFound.Error(), http.StatusNotFound)
+	return nil, false
+}
+
+// applyRecipeForm sets the recipe from the form, where ingredients are written one per line.
+func applyRecipeForm(r *http.Request, rec *recipe.Recipe, userId int64) error {
+	rec.Title = strings.TrimSpace(r.FormValue("title"))
+	if rec.Title == "" {
+		return errors.New("title is required")
+	}
+	rec.Instructions = strings.TrimSpace(r.FormValue("instructions"))
+	rec.Ingredients = recipe.ParseIngredients(r.FormValue("ingredients"))
+	rec.Servings = 1
+	if value := r.FormValue("servings"); value != "" {
+		servings, err := strconv.Atoi(value)
+		if err != nil || servings < 1 {
+			return errors.New("servings should be a positive integer")
+		}
+		rec.Servings = servings
+	}
+	rec.CommunityId = nil
+	if value := r.FormValue("communityId"); value != "" {
+		communityId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return errors.New("communityId should be integer")
+		}
+		comm, err := communityRepository.Get(communityId)
+		if err != nil || !comm.IsMember(userId) {
+			return errors.New("recipes can only be shared with your communities")
+		}
+		rec.CommunityId = &communityId
+	}
+	return nil
+}
+
+func postRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	created := &recipe.Recipe{Creator: *user}
+	if err := applyRecipeForm(r, created, user.Id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := recipesRepository.Save(created); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/recipes/%d", created.Id))
+}
+
+func getRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	found, ok := getVisibleRecipe(w, r, user.Id)
+	if !ok {
+		return
+	}
+	args := &views.RecipeArgs{Recipe: *found, CanEdit: found.Creator.Id == user.Id}
+	page, err := listsRepository.GetAll(user.Id, list.ListsQuery{})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	args.Lists = page.Lists
+	if args.CanEdit {
+		if args.Communities, err = communityRepository.FindMyHouses(user.Id); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	views.Templates.RenderRecipe(w, args)
+}
+
+func putRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	found, ok := getVisibleRecipe(w, r, user.Id)
+	if !ok {
+		return
+	}
+	if found.Creator.Id != user.Id {
+		http.Error(w, "Only the creator can change the recipe", http.StatusForbidden)
+		return
+	}
+	if err := applyRecipeForm(r, found, user.Id); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := recipesRepository.Save(found); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/recipes/%d", found.Id))
+}
+
+func deleteRecipeHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	found, ok := getVisibleRecipe(w, r, user.Id)
+	if !ok {
+		return
+	}
+	if found.Creator.Id != user.Id {
+		http.Error(w, "Only the creator can delete the recipe", http.StatusForbidden)
+		return
+	}
+	if err := recipesRepository.Delete(found.Id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", "/recipes")
+}
+
+// postRecipeToListHandler adds the ingredients of the recipe, for the servings asked, to a group of a list.
+func postRecipeToListHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	found, ok := getVisibleRecipe(w, r, user.Id)
+	if !ok {
+		return
+	}
+	listId, err := strconv.ParseInt(r.FormValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId should be integer", http.StatusBadRequest)
+		return
+	}
+	servings := found.Servings
+	if value := r.FormValue("servings"); value != "" {
+		if servings, err = strconv.Atoi(value); err != nil || servings < 1 {
+			http.Error(w, "servings should be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !canEditList(w, &l, user.Id) {
+		return
+	}
+	if err := recipeService.AddToList(found, listId, r.FormValue("group"), servings); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+}
+
+// getWeekStart returns the monday of the week of the day in the week query param, this week by default.
+func getWeekStart(r *http.Request) (time.Time, error) {
+	value := r.FormValue("week")
+	if value == "" {
+		return mealplan.WeekOf(time.Now()), nil
+	}
+	day, err := time.Parse(mealplan.DayLayout, value)
+	if err != nil {
+		return time.Time{}, errors.New("week should be a date like 2006-01-02")
+	}
+	return mealplan.WeekOf(day), nil
+}
+
+func getMealPlanHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	start, err := getWeekStart(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	week, err := mealPlanService.Week(comm.CommunityId, start)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	visible, err := recipesRepository.FindVisible(user.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	recipes := make([]recipe.Recipe, 0)
+	for _, rec := range visible {
+		if rec.IsSharedWith(comm.CommunityId) {
+			recipes = append(recipes, rec)
+		}
+	}
+	lists, err := listsRepository.GetAll(user.Id, list.ListsQuery{CommunityId: &comm.CommunityId})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	args := &views.MealPlanArgs{Community: comm, Week: week, Recipes: recipes, Lists: lists.Lists}
+	if args.Target, err = nutritionRepository.GetTarget(comm.CommunityId); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	ingredients, err := mealPlanService.Ingredients(week)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	catalogue, err := nutritionCatalogue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	args.Nutrition = &views.NutritionEstimate{Estimate: catalogue.EstimateIngredients(ingredients), Days: 7}
+	args.Nutrition.Comparisons = args.Target.Compare(args.Nutrition.Estimate.Total, args.Nutrition.Days)
+	views.Templates.RenderMealPlan(w, args)
+}
+
+// postMealHandler plans a meal on a day, either a recipe shared with the community or a title written down.
+func postMealHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	day, err := time.Parse(mealplan.DayLayout, r.FormValue("day"))
+	if err != nil {
+		http.Error(w, "day should be a date like 2006-01-02", http.StatusBadRequest)
+		return
+	}
+	meal := &mealplan.Meal{CommunityId: comm.CommunityId, Day: day, Title: strings.TrimSpace(r.FormValue("title")), Servings: 1, CreatorId: user.Id}
+	if value := r.FormValue("recipeId"); value != "" {
+		recipeId, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			http.Error(w, "recipeId should be integer", http.StatusBadRequest)
+			return
+		}
+		found, err := recipesRepository.Get(recipeId)
+		if errors.Is(err, recipe.ErrNotFound) || (err == nil && !found.IsSharedWith(comm.CommunityId)) {
+			http.Error(w, "The recipe must be shared with the community", http.StatusBadRequest)
+			return
+		} else if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		meal.RecipeId = &found.Id
+		meal.Title = found.Title
+		meal.Servings = found.Servings
+	}
+	if meal.Title == "" {
+		http.Error(w, "A recipe or a title is required", http.StatusBadRequest)
+		return
+	}
+	if value := r.FormValue("servings"); value != "" {
+		if meal.Servings, err = strconv.Atoi(value); err != nil || meal.Servings < 1 {
+			http.Error(w, "servings should be a positive integer", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := mealsRepository.Save(meal); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/meals?week=%s", comm.CommunityId, mealplan.WeekOf(day).Format(mealplan.DayLayout)))
+}
+
+func deleteMealHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	mealId, err := strconv.ParseInt(r.PathValue("mealId"), 10, 64)
+	if err != nil {
+		http.Error(w, "mealId path value should be integer", http.StatusBadRequest)
+		return
+	}
+	meal, err := mealsRepository.Get(mealId)
+	if errors.Is(err, mealplan.ErrNotFound) || (err == nil && meal.CommunityId != comm.CommunityId) {
+		http.Error(w, mealplan.ErrNotFound.Error(), http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if err := mealsRepository.Delete(meal.Id); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/meals?week=%s", comm.CommunityId, mealplan.WeekOf(meal.Day).Format(mealplan.DayLayout)))
+}
+
+// postMealPlanListHandler puts the ingredients of the meals of a week on a list of the community,
+// a new one when no listId is sent.
+func postMealPlanListHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	start, err := getWeekStart(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	params := &mealplan.ShoppingListParams{CommunityId: comm.CommunityId, CreatorId: user.Id}
+	if value := r.FormValue("listId"); value != "" {
+		if params.ListId, err = strconv.ParseInt(value, 10, 64); err != nil {
+			http.Error(w, "listId should be integer", http.StatusBadRequest)
+			return
+		}
+		l, err := listsRepository.Get(params.ListId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if l.Community == nil || l.Community.CommunityId != comm.CommunityId || l.DeletedAt != nil {
+			http.Error(w, "The shopping list must be a list of the community", http.StatusBadRequest)
+			return
+		}
+	}
+	if params.Week, err = mealPlanService.Week(comm.CommunityId, start); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	listId, err := mealPlanService.GenerateShoppingList(params)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
+}
+
+func nutritionCatalogue() (*nutrition.Catalogue, error) {
+	foods, err := nutritionRepository.FindFoods()
+	if err != nil {
+		return nil, err
+	}
+	return nutrition.NewCatalogue(foods), nil
+}
+
+// getListNutritionHandler estimates the calories and macros of the items of a list, comparing
+// them to a week of the targets of its community.
+func getListNutritionHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	if !l.IsMember(user.Id) {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return
+	}
+	// Unsaved changes are estimated too
+	current := &l
+	if live, _ := liveEditor.Snapshot(listId); live != nil {
+		current = live.List
+	}
+	catalogue, err := nutritionCatalogue()
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	estimate := &views.NutritionEstimate{Estimate: catalogue.EstimateList(current), Days: 7}
+	if l.Community != nil {
+		target, err := nutritionRepository.GetTarget(l.Community.CommunityId)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		estimate.Comparisons = target.Compare(estimate.Estimate.Total, estimate.Days)
+	}
+	views.Templates.RenderNutrition(w, &views.NutritionArgs{List: current, Nutrition: estimate})
+}
+
+// putNutritionTargetHandler sets what the members of a community aim to eat in a day, a missing value having no target.
+func putNutritionTargetHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	comm, ok := getCommunityOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	target := &nutrition.Target{CommunityId: comm.CommunityId}
+	values := []*float64{&target.Daily.Calories, &target.Daily.Protein, &target.Daily.Carbs, &target.Daily.Fat}
+	for i, field := range []string{"calories", "protein", "carbs", "fat"} {
+		value := r.FormValue(field)
+		if value == "" {
+			continue
+		}
+		if *values[i], err = units.ParseAmount(value); err != nil {
+			http.Error(w, field+" should be a positive number", http.StatusBadRequest)
+			return
+		}
+	}
+	if err := nutritionRepository.SaveTarget(target); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities/%d/meals?week=%s", comm.CommunityId, r.FormValue("week")))
+}
+
+// getDeliveryListOfMember returns the list in the path, with its unsaved changes, if the user is a member
+// of it and a delivery provider is set up.
+func getDeliveryListOfMember(w http.ResponseWriter, r *http.Request, userId int64) (*list.List, bool) {
+	if deliveryService == nil {
+		http.Error(w, "No delivery provider is set up", http.StatusNotFound)
+		return nil, false
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return nil, false
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !l.IsMember(userId) {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return nil, false
+	}
+	if current, _ := liveEditor.Snapshot(listId); current != nil {
+		return current.List, true
+	}
+	return &l, true
 }
 
-type HotReloadMessage struct {
-	ServerRunId string `json:"serverRunId"`
+// getListDeliveryHandler previews the order of the items of the list still to buy.
+func getListDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, ok := getDeliveryListOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	lines, err := deliveryService.Preview(l)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	views.Templates.RenderDelivery(w, views.NewDeliveryArgs(l, deliveryService.Provider.Name(), lines))
 }
 
-var ServerRunID string
-
-func removeHotReloadCon(conn *websocket.Conn) {
-	for i, c := range allconns {
-		if c == conn {
-			allconns = append(allconns[:i], allconns[i+1:]...)
-			return
-		}
+func postListOrderHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, ok := getDeliveryListOfMember(w, r, user.Id)
+	if !ok || !canEditList(w, l, user.Id) {
+		return
 	}
+	if _, err := deliveryService.Submit(l, user.Id); errors.Is(err, delivery.ErrOrderOpen) || errors.Is(err, delivery.ErrNothingToOrder) {
+		http.Error(w, err.Error(), http.StatusConflict)
+		return
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusBadGateway)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", l.Id))
 }
 
-func handleHotReload(conn *websocket.Conn) {
-	defer conn.Close()
-	for {
-		hotReloadMessage := &HotReloadMessage{}
-		err := conn.ReadJSON(hotReloadMessage)
-		if err != nil {
-			removeHotReloadCon(conn)
-			return
-		}
-		conn.WriteJSON(&HotReloadMessage{ServerRunId: ServerRunID})
+// getListOrdersHandler renders the orders of the list, which keep being fetched while one is open.
+func getListOrdersHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
+	}
+	l, ok := getDeliveryListOfMember(w, r, user.Id)
+	if !ok {
+		return
+	}
+	orders, err := deliveryService.Orders.FindByList(l.Id)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
 	}
+	views.Templates.RenderDeliveryOrders(w, &views.DeliveryOrdersArgs{ListId: l.Id, Orders: orders})
 }
 
-func postCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+func getTrashHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
 	user, err := session.GetUserFromSession(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	community := &community.Community{}
-	mountCommunityFromRequest(r, user, community)
-	community.CreatedBy = user
-	_, err = communityRepository.Save(community, nil)
+	trash, err := listsRepository.FindTrash(user.Id)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Add("HX-Redirect", "/communities")
+	views.Templates.RenderTrash(w, &views.TrashArgs{
+		Trash:         trash,
+		RetentionDays: int(config.GetConfig().TrashRetention.Hours() / 24),
+	})
 }
 
-type CommunityCreationParams struct {
-	CommunityName string   `json:"communityName"`
-	MemberIds     []string `json:"members"`
+// trashError answers with not found when there was nothing of the user to restore or purge.
+func trashError(w http.ResponseWriter, err error) {
+	if errors.Is(err, list.ErrNotInTrash) {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	http.Error(w, err.Error(), http.StatusInternalServerError)
 }
 
-func mountCommunityFromRequest(r *http.Request, u *user.User, c *community.Community) error {
-	params := &CommunityCreationParams{}
-	err := json.NewDecoder(r.Body).Decode(params)
+func postListRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
 	if err != nil {
-		return err
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
 	}
-	c.CommunityName = params.CommunityName
-	c.CreatedBy = u
-	c.Members = make([]*community.Member, 0)
-	c.Default = false
-	for _, memberId := range params.MemberIds {
-		intMemberId, err := strconv.Atoi(memberId)
-		if err != nil {
-			return err
-		}
-		member, err := usersRepository.Get(int64(intMemberId))
-		if err != nil {
-			return err
-		}
-		c.Members = append(c.Members, &community.Member{User: member})
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return
 	}
-	return nil
+	if err := listsRepository.Restore(listId, user.Id); err != nil {
+		trashError(w, err)
+		return
+	}
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
 }
 
-func putCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+func deleteListPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return
+	}
 	user, err := session.GetUserFromSession(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	community := &community.Community{}
-	mountCommunityFromRequest(r, user, community)
-	community.UpdatedAt = time.Now()
-	communityId, err := strconv.Atoi(r.PathValue("communityId"))
+	files, err := listsRepository.Purge(listId, user.Id)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		trashError(w, err)
 		return
 	}
-	community.CommunityId = int64(communityId)
-	_, err = communityRepository.Save(community, &user.Id)
+	attachmentService.DeleteFiles(files...)
+	w.Header().Add("HX-Redirect", "/trash")
+}
+
+// getTrashedGroupPath reads the list and group in the path, checking the logged user can edit the list.
+func getTrashedGroupPath(w http.ResponseWriter, r *http.Request) (listId int64, groupId int64, ok bool) {
+	if redirectIfNotLoggedIn(w, r) {
+		return 0, 0, false
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	groupId, err = strconv.ParseInt(r.PathValue("groupId"), 10, 64)
+	if err != nil {
+		http.Error(w, "groupId path value should be integer", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return 0, 0, false
+	}
+	l, err := listsRepository.Get(listId)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, 0, false
+	}
+	if !canEditList(w, &l, user.Id) {
+		return 0, 0, false
+	}
+	return listId, groupId, true
+}
+
+func postGroupRestoreHandler(w http.ResponseWriter, r *http.Request) {
+	listId, groupId, ok := getTrashedGroupPath(w, r)
+	if !ok {
 		return
 	}
-	w.Header().Add("HX-Redirect", fmt.Sprintf("/communities?selectedId=%d", community.CommunityId))
+	if err := listsRepository.RestoreGroup(listId, groupId); err != nil {
+		trashError(w, err)
+		return
+	}
+	liveEditor.RestoreGroup(listId, groupId)
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", listId))
 }
 
-func deleteCommunitiesHandler(w http.ResponseWriter, r *http.Request) {
+func deleteGroupPurgeHandler(w http.ResponseWriter, r *http.Request) {
+	listId, groupId, ok := getTrashedGroupPath(w, r)
+	if !ok {
+		return
+	}
+	files, err := listsRepository.PurgeGroup(listId, groupId)
+	if err != nil {
+		trashError(w, err)
+		return
+	}
+	attachmentService.DeleteFiles(files...)
+	liveEditor.PurgeGroup(listId, groupId)
+	w.Header().Add("HX-Redirect", "/trash")
+}
+
+func postItemAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	if redirectIfNotLoggedIn(w, r) {
+		return
+	}
+	itemId, err := strconv.ParseInt(r.PathValue("itemId"), 10, 64)
+	if err != nil {
+		http.Error(w, "itemId path value should be integer", http.StatusBadRequest)
+		return
+	}
 	user, err := session.GetUserFromSession(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
 		return
 	}
-	communityId, err := strconv.ParseInt(r.PathValue("communityId"), 10, 64)
+	listId, groupId, ok := getItemOfMember(w, itemId, user.Id, true)
+	if !ok {
+		return
+	}
+	r.Body = http.MaxBytesReader(w, r.Body, attachment.MaxSize+1<<20)
+	file, header, err := r.FormFile("file")
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
-	err = communityRepository.Delete(communityId, user.Id)
+	defer file.Close()
+	// Ids of unsaved items only mean something in their list, the same id can be of an item saved in another one
+	if r.FormValue("listId") != strconv.FormatInt(listId, 10) {
+		http.Error(w, "Item not found, save the list before attaching to new items", http.StatusNotFound)
+		return
+	}
+	data, err := io.ReadAll(io.LimitReader(file, attachment.MaxSize+1))
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if _, err := attachmentService.Add(itemId, user.Id, header.Filename, data); err != nil {
+		if errors.Is(err, attachment.ErrNotAnImage) || errors.Is(err, attachment.ErrTooLarge) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Add("HX-Redirect", "/communities")
+	broadcastItemAttachments(listId, groupId, itemId)
+	w.WriteHeader(http.StatusCreated)
 }
 
-func getPasswordRecoveryHandler(w http.ResponseWriter, r *http.Request) {
-	views.Templates.RenderPasswordRecovery(w, &views.PasswordRecoveryArgs{})
+// getItemOfMember finds where a saved item is, checking the user is a member of its list, and an editor when edit is set.
+func getItemOfMember(w http.ResponseWriter, itemId int64, userId int64, edit bool) (listId int64, groupId int64, ok bool) {
+	listId, groupId, err := listsRepository.FindItem(itemId)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Item not found, save the list before attaching to new items", http.StatusNotFound)
+		return 0, 0, false
+	} else if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, 0, false
+	}
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return 0, 0, false
+	}
+	if edit {
+		if !canEditList(w, &l, userId) {
+			return 0, 0, false
+		}
+	} else if !l.IsMember(userId) {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return 0, 0, false
+	}
+	return listId, groupId, true
 }
 
-func postPasswordRecoveryHandler(w http.ResponseWriter, r *http.Request) {
-	password := r.FormValue("password")
-	token := r.FormValue("token")
-	if err := recoveryService.RecoverPassword(password, token); err != nil {
+func broadcastItemAttachments(listId, groupId, itemId int64) {
+	attachments, err := attachmentService.Repository.FindByItem(itemId)
+	if err != nil {
+		log.Println("Error finding attachments of item", itemId, err)
+		return
+	}
+	liveEditor.SetItemAttachments(listId, groupId, itemId, attachments)
+}
+
+// getAttachmentOfMember loads the attachment in the path, checking the logged user is a member of its list,
+// and an editor when edit is set.
+func getAttachmentOfMember(w http.ResponseWriter, r *http.Request, edit bool) (a attachment.Attachment, listId int64, groupId int64, ok bool) {
+	if redirectIfNotLoggedIn(w, r) {
+		return a, 0, 0, false
+	}
+	attachmentId, err := strconv.ParseInt(r.PathValue("attachmentId"), 10, 64)
+	if err != nil {
+		http.Error(w, "attachmentId path value should be integer", http.StatusBadRequest)
+		return a, 0, 0, false
+	}
+	user, err := session.GetUserFromSession(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusUnauthorized)
+		return a, 0, 0, false
+	}
+	a, err = attachmentService.Repository.Get(attachmentId)
+	if errors.Is(err, sql.ErrNoRows) {
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+		return a, 0, 0, false
+	} else if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return a, 0, 0, false
 	}
+	listId, groupId, ok = getItemOfMember(w, a.ItemId, user.Id, edit)
+	return a, listId, groupId, ok
 }
 
-func getPasswordRecoveryRequestHandler(w http.ResponseWriter, r *http.Request) {
-	views.Templates.RenderPasswordRecoveryRequest(w, &views.PasswordRecoveryRequestArgs{})
+func getAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	serveAttachment(w, r, false)
 }
 
-func postPasswordRecoveryRequestHandler(w http.ResponseWriter, r *http.Request) {
-	email := r.FormValue("email")
-	if _, err := mail.ParseAddress(email); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+func getAttachmentThumbnailHandler(w http.ResponseWriter, r *http.Request) {
+	serveAttachment(w, r, true)
+}
+
+func serveAttachment(w http.ResponseWriter, r *http.Request, thumbnail bool) {
+	a, _, _, ok := getAttachmentOfMember(w, r, false)
+	if !ok {
 		return
 	}
-	if err := recoveryService.CreatePasswordRecoveryRequest(email); err != nil {
+	file, contentType, err := attachmentService.Open(&a, thumbnail)
+	if err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	http.Redirect(w, r, "/login", http.StatusSeeOther)
+	defer file.Close()
+	w.Header().Set("Content-Type", contentType)
+	// Files of an attachment never change
+	w.Header().Set("Cache-Control", "private, max-age=31536000, immutable")
+	io.Copy(w, file)
 }
 
-func deleteListHandler(w http.ResponseWriter, r *http.Request) {
-	if redirectIfNotLoggedIn(w, r) {
+func deleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	a, listId, groupId, ok := getAttachmentOfMember(w, r, true)
+	if !ok {
 		return
 	}
-	lisetId := r.PathValue("listId")
-	id, err := strconv.ParseInt(lisetId, 10, 64)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	if err := attachmentService.Remove(&a); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+	broadcastItemAttachments(listId, groupId, a.ItemId)
+}
 
+// getReminderPreference loads the reminder preference of the logged user for the list in the path.
+func getReminderPreference(w http.ResponseWriter, r *http.Request) (*reminder.Preference, bool) {
+	if redirectIfNotLoggedIn(w, r) {
+		return nil, false
+	}
+	listId, err := strconv.ParseInt(r.PathValue("listId"), 10, 64)
+	if err != nil {
+		http.Error(w, "listId path value should be integer", http.StatusBadRequest)
+		return nil, false
+	}
 	user, err := session.GetUserFromSession(r)
 	if err != nil {
 		http.Error(w, err.Error(), http.StatusUnauthorized)
-		return
+		return nil, false
 	}
-	err = listsRepository.Delete(id, user.Id)
+	l, err := listsRepository.Get(listId)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	if !l.IsMember(user.Id) {
+		http.Error(w, "You are not a member of this list", http.StatusForbidden)
+		return nil, false
+	}
+	preference, err := remindersRepository.GetPreference(listId, user.Id)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return nil, false
+	}
+	return &preference, true
+}
+
+func saveReminderPreference(w http.ResponseWriter, preference *reminder.Preference) {
+	if err := remindersRepository.SavePreference(preference); err != nil {
 		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
-	w.Header().Add("HX-Redirect", "/lists")
+	w.Header().Add("HX-Redirect", fmt.Sprintf("/lists/%d", preference.ListId))
+}
+
+func postReminderSnoozeHandler(w http.ResponseWriter, r *http.Request) {
+	preference, ok := getReminderPreference(w, r)
+	if !ok {
+		return
+	}
+	duration := 24 * time.Hour
+	if value := r.FormValue("duration"); value != "" {
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			http.Error(w, "duration should be like 1h or 24h", http.StatusBadRequest)
+			return
+		}
+		duration = d
+	}
+	until := time.Now().Add(duration)
+	preference.SnoozedUntil = &until
+	saveReminderPreference(w, preference)
+}
+
+func postReminderOptOutHandler(w http.ResponseWriter, r *http.Request) {
+	preference, ok := getReminderPreference(w, r)
+	if !ok {
+		return
+	}
+	preference.OptedOut = true
+	saveReminderPreference(w, preference)
+}
+
+func deleteReminderOptOutHandler(w http.ResponseWriter, r *http.Request) {
+	preference, ok := getReminderPreference(w, r)
+	if !ok {
+		return
+	}
+	preference.OptedOut = false
+	preference.SnoozedUntil = nil
+	saveReminderPreference(w, preference)
 }
 
 func main() {
@@ -605,6 +2640,22 @@ func main() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	storage, err := attachment.NewLocalStorage(config.AttachmentsDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	attachmentService = &attachment.Service{Storage: storage, Repository: &attachment.SqlAttachmentsRepository{}}
+	pantryService.Live = liveEditor
+	if config.DeliveryFake {
+		// Orders move on every step, to see them through in a few minutes
+		if config.DeliveryUrl, err = delivery.ServeFake("127.0.0.1:0", time.Minute); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Fake delivery provider started at %s\n", config.DeliveryUrl)
+	}
+	if config.DeliveryUrl != "" {
+		deliveryService = &delivery.Service{Provider: delivery.NewHttpProvider(config.DeliveryUrl), Orders: &delivery.SqlOrdersRepository{}}
+	}
 	//
 	http.HandleFunc("GET /login", getLoginHandler)
 	http.HandleFunc("POST /login", postLoginHandler)
@@ -615,12 +2666,58 @@ func main() {
 	http.HandleFunc("GET /lists", getListsHandler)
 	http.HandleFunc("POST /lists", postListsHandler)
 	http.HandleFunc("GET /lists/{listId}", getListDetailHandler)
+	http.HandleFunc("GET /my-items", getMyItemsHandler)
+	http.HandleFunc("GET /search", getSearchHandler)
 	http.HandleFunc("DELETE /lists/{listId}", deleteListHandler)
+	http.HandleFunc("GET /trash", getTrashHandler)
+	http.HandleFunc("GET /purchases", getPurchasesHandler)
+	http.HandleFunc("GET /recipes", getRecipesHandler)
+	http.HandleFunc("POST /recipes", postRecipeHandler)
+	http.HandleFunc("GET /recipes/{recipeId}", getRecipeHandler)
+	http.HandleFunc("PUT /recipes/{recipeId}", putRecipeHandler)
+	http.HandleFunc("DELETE /recipes/{recipeId}", deleteRecipeHandler)
+	http.HandleFunc("POST /recipes/{recipeId}/list", postRecipeToListHandler)
+	http.HandleFunc("GET /communities/{communityId}/pantry", getPantryHandler)
+	http.HandleFunc("PUT /communities/{communityId}/pantry", putPantryHandler)
+	http.HandleFunc("POST /communities/{communityId}/pantry/items", postPantryItemHandler)
+	http.HandleFunc("PUT /communities/{communityId}/pantry/items/{itemId}", putPantryItemHandler)
+	http.HandleFunc("DELETE /communities/{communityId}/pantry/items/{itemId}", deletePantryItemHandler)
+	http.HandleFunc("POST /communities/{communityId}/pantry/items/{itemId}/use", postPantryItemUseHandler)
+	http.HandleFunc("GET /communities/{communityId}/meals", getMealPlanHandler)
+	http.HandleFunc("POST /communities/{communityId}/meals", postMealHandler)
+	http.HandleFunc("DELETE /communities/{communityId}/meals/{mealId}", deleteMealHandler)
+	http.HandleFunc("POST /communities/{communityId}/meals/list", postMealPlanListHandler)
+	http.HandleFunc("PUT /communities/{communityId}/nutrition-target", putNutritionTargetHandler)
+	http.HandleFunc("GET /lists/{listId}/nutrition", getListNutritionHandler)
+	http.HandleFunc("GET /lists/{listId}/delivery", getListDeliveryHandler)
+	http.HandleFunc("GET /lists/{listId}/orders", getListOrdersHandler)
+	http.HandleFunc("POST /lists/{listId}/orders", postListOrderHandler)
+	http.HandleFunc("POST /lists/{listId}/restore", postListRestoreHandler)
+	http.HandleFunc("DELETE /lists/{listId}/purge", deleteListPurgeHandler)
+	http.HandleFunc("POST /lists/{listId}/groups/{groupId}/restore", postGroupRestoreHandler)
+	http.HandleFunc("DELETE /lists/{listId}/groups/{groupId}", deleteGroupPurgeHandler)
 	http.HandleFunc("GET /api/users/{userId}", getUserHandler)
 	http.HandleFunc("GET /api/users", getUsersHandler)
+	http.HandleFunc("GET /api/suggestions", getSuggestionsHandler)
 	http.HandleFunc("GET /ws/list-editor", getListEditorHandler)
 	http.HandleFunc("PUT /lists/{listId}/save", putListSaveHandler)
 	http.HandleFunc("PUT /lists/{listId}", putListHandler)
+	http.HandleFunc("POST /lists/{listId}/owner", postListOwnerHandler)
+	http.HandleFunc("POST /lists/{listId}/shares", postListShareHandler)
+	http.HandleFunc("DELETE /lists/{listId}/shares/{shareId}", deleteListShareHandler)
+	http.HandleFunc("GET /shared/{token}", getSharedListHandler)
+	http.HandleFunc("GET /ws/shared", getSharedEditorHandler)
+	http.HandleFunc("POST /lists/{listId}/duplicate", postListDuplicateHandler)
+	http.HandleFunc("POST /lists/{listId}/template", postListTemplateHandler)
+	http.HandleFunc("GET /lists/{listId}/merge", getListMergeHandler)
+	http.HandleFunc("POST /lists/{listId}/merge", postListMergeHandler)
+	http.HandleFunc("POST /lists/{listId}/reminders/snooze", postReminderSnoozeHandler)
+	http.HandleFunc("POST /lists/{listId}/reminders/opt-out", postReminderOptOutHandler)
+	http.HandleFunc("DELETE /lists/{listId}/reminders/opt-out", deleteReminderOptOutHandler)
+	http.HandleFunc("POST /items/{itemId}/attachments", postItemAttachmentHandler)
+	http.HandleFunc("GET /attachments/{attachmentId}", getAttachmentHandler)
+	http.HandleFunc("GET /attachments/{attachmentId}/thumbnail", getAttachmentThumbnailHandler)
+	http.HandleFunc("DELETE /attachments/{attachmentId}", deleteAttachmentHandler)
 	http.HandleFunc("GET /communities", getCommunitiesHandler)
 	http.HandleFunc("POST /communities", postCommunitiesHandler)
 	http.HandleFunc("PUT /communities/{communityId}", putCommunitiesHandler)
@@ -635,6 +2732,13 @@ func main() {
 		http.HandleFunc("GET /ws/hot-reload", getHotReloadHandler)
 	}
 
+	go reminder.NewScheduler(listsRepository, remindersRepository, infra.SendEmail, config.ReminderOffsets, config.ReminderInterval, config.AppUrl).Run()
+	go recurrence.NewScheduler(listsRepository, liveEditor, config.RecurrenceInterval).Run()
+	go trash.NewScheduler(listsRepository, attachmentService, config.TrashRetention, config.TrashInterval).Run()
+	if deliveryService != nil {
+		go delivery.NewScheduler(deliveryService, config.DeliveryInterval).Run()
+	}
+
 	log.Printf("Server started at %s\n", config.Listen)
 	httpServer := http.Server{
 		Addr:              config.Listen,
@@ -0,0 +1,2 @@
+-- Empty color means the user never picked one; a default is derived from luserId.
+ALTER TABLE luser ADD COLUMN color TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,11 @@
+CREATE TABLE shopping_trip (
+  tripId INTEGER PRIMARY KEY AUTOINCREMENT,
+  listId INTEGER REFERENCES list(listId),
+  communityId INTEGER REFERENCES community(communityId),
+  shopperLuserId INTEGER REFERENCES luser(luserId),
+  startedAt TIMESTAMP NOT NULL,
+  endedAt TIMESTAMP NOT NULL,
+  itemsBought INTEGER NOT NULL DEFAULT 0,
+  itemsMissing INTEGER NOT NULL DEFAULT 0,
+  missingItems TEXT NOT NULL DEFAULT '' -- descriptions of missing items, one per line
+);
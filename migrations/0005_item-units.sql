@@ -0,0 +1,18 @@
+-- Quantities become decimal and items get a unit from pkg/units (empty means "unit").
+CREATE TABLE list_group_items_new (
+  itemId INTEGER PRIMARY KEY AUTOINCREMENT,
+  groupId INTEGER,
+  description TEXT,
+  quantity REAL,
+  order_ INTEGER,
+  checked INTEGER DEFAULT 0,
+  unit TEXT NOT NULL DEFAULT '',
+  FOREIGN KEY (groupId) REFERENCES list_groups(groupId) ON DELETE CASCADE
+);
+
+INSERT INTO list_group_items_new (itemId, groupId, description, quantity, order_, checked)
+SELECT itemId, groupId, description, quantity, order_, checked FROM list_group_items;
+
+DROP TABLE list_group_items;
+
+ALTER TABLE list_group_items_new RENAME TO list_group_items;
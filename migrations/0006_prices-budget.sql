@@ -0,0 +1,7 @@
+-- Price of one unit of the item (per kg, per pack, ...) and its currency.
+-- An empty item currency means the currency of the list.
+ALTER TABLE list_group_items ADD COLUMN unitPrice REAL;
+ALTER TABLE list_group_items ADD COLUMN currency TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE list ADD COLUMN budget REAL;
+ALTER TABLE list ADD COLUMN currency TEXT NOT NULL DEFAULT '';
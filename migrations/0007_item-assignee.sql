@@ -0,0 +1,2 @@
+-- Member of the list responsible for buying the item
+ALTER TABLE list_group_items ADD COLUMN assigneeLuserId INTEGER REFERENCES luser(luserId);
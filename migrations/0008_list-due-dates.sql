@@ -0,0 +1,18 @@
+ALTER TABLE list ADD COLUMN dueAt TIMESTAMP;
+
+-- Reminders already emailed, one per offset before the due date
+CREATE TABLE list_reminder_sent (
+  listId INTEGER NOT NULL REFERENCES list(listId),
+  luserId INTEGER NOT NULL REFERENCES luser(luserId),
+  offsetSeconds INTEGER NOT NULL,
+  sentAt TIMESTAMP NOT NULL,
+  PRIMARY KEY (listId, luserId, offsetSeconds)
+);
+
+CREATE TABLE list_reminder_preference (
+  listId INTEGER NOT NULL REFERENCES list(listId),
+  luserId INTEGER NOT NULL REFERENCES luser(luserId),
+  snoozedUntil TIMESTAMP,
+  optedOut INTEGER NOT NULL DEFAULT 0,
+  PRIMARY KEY (listId, luserId)
+);
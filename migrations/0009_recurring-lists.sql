@@ -0,0 +1,8 @@
+-- A recurring list has a rule (daily, weekly, monthly or cron) and, at each
+-- occurrence, either has its items unchecked or is replaced by a fresh copy.
+-- Previous occurrences are kept archived, sharing the seriesId of the first list.
+ALTER TABLE list ADD COLUMN recurrence TEXT NOT NULL DEFAULT '';
+ALTER TABLE list ADD COLUMN recurrenceMode TEXT NOT NULL DEFAULT 'reset';
+ALTER TABLE list ADD COLUMN nextOccurrenceAt TIMESTAMP;
+ALTER TABLE list ADD COLUMN seriesId INTEGER REFERENCES list(listId);
+ALTER TABLE list ADD COLUMN archivedAt TIMESTAMP;
@@ -0,0 +1,2 @@
+-- Templates are copied into new lists. A template with a community is shared with its members.
+ALTER TABLE list ADD COLUMN isTemplate INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- Deleted lists and groups go to the trash, from where they can be restored until purged.
+ALTER TABLE list ADD COLUMN deletedAt TIMESTAMP;
+ALTER TABLE list_groups ADD COLUMN deletedAt TIMESTAMP;
@@ -0,0 +1,13 @@
+ALTER TABLE list_group_items ADD COLUMN note TEXT NOT NULL DEFAULT '';
+-- Files are kept in the attachments storage, under storageKey and thumbnailKey
+CREATE TABLE item_attachment (
+  attachmentId INTEGER PRIMARY KEY AUTOINCREMENT,
+  itemId INTEGER NOT NULL REFERENCES list_group_items(itemId),
+  fileName TEXT NOT NULL,
+  contentType TEXT NOT NULL,
+  storageKey TEXT NOT NULL,
+  thumbnailKey TEXT NOT NULL,
+  uploadedByLuserId INTEGER REFERENCES luser(luserId),
+  createdAt TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE INDEX item_attachment_itemId ON item_attachment(itemId);
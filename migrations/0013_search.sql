@@ -0,0 +1,16 @@
+-- Full-text index of the lists, one row per list with rowid = listId.
+-- Requires SQLite built with FTS5, see the sqlite_fts5 build tag in the README.
+CREATE VIRTUAL TABLE list_fts USING fts5(
+  title,
+  description,
+  groups,
+  items,
+  tokenize = 'unicode61 remove_diacritics 2'
+);
+INSERT INTO list_fts (rowid, title, description, groups, items)
+SELECT l.listId, l.title, l.description,
+  (SELECT group_concat(g.name, ' | ') FROM list_groups g WHERE g.listId = l.listId AND g.deletedAt IS NULL),
+  (SELECT group_concat(i.description, ' | ') FROM list_group_items i
+    INNER JOIN list_groups g ON g.groupId = i.groupId
+    WHERE g.listId = l.listId AND g.deletedAt IS NULL)
+FROM list l;
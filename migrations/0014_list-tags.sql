@@ -0,0 +1,7 @@
+-- Tags are shared by the members of the list
+CREATE TABLE list_tag (
+  listId INTEGER NOT NULL REFERENCES list(listId),
+  tag TEXT NOT NULL,
+  PRIMARY KEY (listId, tag)
+);
+CREATE INDEX list_tag_tag ON list_tag(tag);
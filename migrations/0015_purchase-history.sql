@@ -0,0 +1,17 @@
+-- One row per item checked on a list, kept when the list is reset, emptied or purged
+CREATE TABLE purchase (
+  purchaseId INTEGER PRIMARY KEY AUTOINCREMENT,
+  listId INTEGER NOT NULL,
+  communityId INTEGER REFERENCES community(communityId),
+  itemId INTEGER NOT NULL, -- id of the item when it was checked, may be gone since
+  buyerLuserId INTEGER NOT NULL REFERENCES luser(luserId),
+  description TEXT NOT NULL,
+  quantity REAL NOT NULL,
+  unit TEXT NOT NULL DEFAULT '',
+  unitPrice REAL,
+  currency TEXT NOT NULL DEFAULT '',
+  purchasedAt TIMESTAMP NOT NULL
+);
+CREATE INDEX purchase_buyer ON purchase(buyerLuserId, purchasedAt);
+CREATE INDEX purchase_community ON purchase(communityId, purchasedAt);
+CREATE INDEX purchase_item ON purchase(listId, itemId);
@@ -0,0 +1,15 @@
+-- What each community keeps at home
+CREATE TABLE pantry (
+  communityId INTEGER PRIMARY KEY REFERENCES community(communityId),
+  shoppingListId INTEGER REFERENCES list(listId) -- where items running low are added
+);
+CREATE TABLE pantry_item (
+  pantryItemId INTEGER PRIMARY KEY AUTOINCREMENT,
+  communityId INTEGER NOT NULL REFERENCES community(communityId),
+  description TEXT NOT NULL,
+  quantity REAL NOT NULL DEFAULT 0,
+  unit TEXT NOT NULL DEFAULT '',
+  minimum REAL NOT NULL DEFAULT 0, -- in the unit of the item
+  updatedAt TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX pantry_item_description ON pantry_item(communityId, description COLLATE NOCASE);
@@ -0,0 +1,21 @@
+CREATE TABLE recipe (
+  recipeId INTEGER PRIMARY KEY AUTOINCREMENT,
+  title TEXT NOT NULL,
+  instructions TEXT NOT NULL DEFAULT '',
+  servings INTEGER NOT NULL DEFAULT 1, -- the quantities of the ingredients are for this many
+  creatorLuserId INTEGER NOT NULL REFERENCES luser(luserId),
+  communityId INTEGER REFERENCES community(communityId), -- shared with its members when set
+  createdAt TIMESTAMP NOT NULL,
+  updatedAt TIMESTAMP NOT NULL
+);
+CREATE INDEX recipe_creator ON recipe(creatorLuserId);
+CREATE INDEX recipe_community ON recipe(communityId);
+CREATE TABLE recipe_ingredient (
+  ingredientId INTEGER PRIMARY KEY AUTOINCREMENT,
+  recipeId INTEGER NOT NULL REFERENCES recipe(recipeId),
+  description TEXT NOT NULL,
+  quantity REAL NOT NULL,
+  unit TEXT NOT NULL DEFAULT '',
+  order_ INTEGER NOT NULL DEFAULT 0
+);
+CREATE INDEX recipe_ingredient_recipe ON recipe_ingredient(recipeId);
@@ -0,0 +1,11 @@
+CREATE TABLE meal (
+  mealId INTEGER PRIMARY KEY AUTOINCREMENT,
+  communityId INTEGER NOT NULL REFERENCES community(communityId),
+  day TEXT NOT NULL, -- YYYY-MM-DD
+  title TEXT NOT NULL, -- the meal written down, or the title of the recipe when it was planned
+  recipeId INTEGER REFERENCES recipe(recipeId), -- its ingredients are bought for the meal when set
+  servings INTEGER NOT NULL DEFAULT 1,
+  creatorLuserId INTEGER NOT NULL REFERENCES luser(luserId),
+  createdAt TIMESTAMP NOT NULL
+);
+CREATE INDEX meal_community_day ON meal(communityId, day);
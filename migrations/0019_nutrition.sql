@@ -0,0 +1,19 @@
+CREATE TABLE nutrition_food (
+  foodId INTEGER PRIMARY KEY AUTOINCREMENT,
+  name TEXT NOT NULL COLLATE NOCASE UNIQUE,
+  aliases TEXT NOT NULL DEFAULT '', -- separated by |
+  per REAL NOT NULL, -- the facts are for this amount of the unit
+  unit TEXT NOT NULL DEFAULT '',
+  calories REAL NOT NULL DEFAULT 0,
+  protein REAL NOT NULL DEFAULT 0,
+  carbs REAL NOT NULL DEFAULT 0,
+  fat REAL NOT NULL DEFAULT 0
+);
+CREATE TABLE nutrition_target (
+  communityId INTEGER PRIMARY KEY REFERENCES community(communityId),
+  calories REAL NOT NULL DEFAULT 0, -- for a day, of all the members together
+  protein REAL NOT NULL DEFAULT 0,
+  carbs REAL NOT NULL DEFAULT 0,
+  fat REAL NOT NULL DEFAULT 0,
+  updatedAt TIMESTAMP NOT NULL
+);
@@ -0,0 +1,27 @@
+CREATE TABLE delivery_order (
+  orderId INTEGER PRIMARY KEY AUTOINCREMENT,
+  listId INTEGER NOT NULL REFERENCES list(listId),
+  provider TEXT NOT NULL,
+  externalId TEXT NOT NULL, -- id of the order at the provider
+  status TEXT NOT NULL,
+  total REAL NOT NULL DEFAULT 0,
+  currency TEXT NOT NULL DEFAULT '',
+  creatorLuserId INTEGER NOT NULL REFERENCES luser(luserId),
+  createdAt TIMESTAMP NOT NULL,
+  updatedAt TIMESTAMP NOT NULL
+);
+CREATE INDEX delivery_order_list ON delivery_order(listId);
+CREATE INDEX delivery_order_status ON delivery_order(status);
+CREATE TABLE delivery_order_line (
+  lineId INTEGER PRIMARY KEY AUTOINCREMENT,
+  orderId INTEGER NOT NULL REFERENCES delivery_order(orderId),
+  itemId INTEGER, -- the item of the list, which may have been deleted since
+  description TEXT NOT NULL,
+  quantity REAL NOT NULL,
+  unit TEXT NOT NULL DEFAULT '',
+  productId TEXT NOT NULL,
+  productName TEXT NOT NULL,
+  packages INTEGER NOT NULL,
+  price REAL NOT NULL
+);
+CREATE INDEX delivery_order_line_order ON delivery_order_line(orderId);
@@ -0,0 +1,4 @@
+-- owner, editor, commenter or viewer; the colaborators so far could edit everything
+ALTER TABLE list_colaborators ADD COLUMN role TEXT NOT NULL DEFAULT 'editor';
+UPDATE list_colaborators SET role = 'owner'
+WHERE luserId = (SELECT creatorLuserId FROM list WHERE list.listId = list_colaborators.listId);
@@ -0,0 +1,11 @@
+CREATE TABLE list_share (
+  shareId INTEGER PRIMARY KEY AUTOINCREMENT,
+  listId INTEGER NOT NULL REFERENCES list(listId),
+  token TEXT NOT NULL UNIQUE,
+  canCheck INTEGER NOT NULL DEFAULT 0, -- whoever has the link can check items off
+  expiresAt TIMESTAMP, -- in UTC, never when null
+  revokedAt TIMESTAMP,
+  creatorLuserId INTEGER NOT NULL REFERENCES luser(luserId),
+  createdAt TIMESTAMP NOT NULL
+);
+CREATE INDEX list_share_list ON list_share(listId);
@@ -36,7 +36,7 @@ func MockItem() *list.Item {
 		Id:          IdItemCurrent + 1,
 		Order:       IdItemCurrent + 1,
 		Description: fmt.Sprintf("Item %d", IdItemCurrent),
-		Quantity:    rand.Intn(5),
+		Quantity:    float64(rand.Intn(5)),
 	}
 	IdItemCurrent += 1
 	return item
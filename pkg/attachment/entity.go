@@ -0,0 +1,36 @@
+package attachment
+
+import (
+	"errors"
+	"time"
+)
+
+// Attachment is an image uploaded to an item of a list.
+type Attachment struct {
+	AttachmentId int64     `json:"attachmentId"`
+	ItemId       int64     `json:"itemId"`
+	FileName     string    `json:"fileName"`
+	ContentType  string    `json:"contentType"`
+	StorageKey   string    `json:"-"`
+	ThumbnailKey string    `json:"-"`
+	UploadedById *int64    `json:"uploadedById"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+// MaxSize is the largest file accepted as an attachment, in bytes.
+const MaxSize = 10 << 20
+
+// ThumbnailSize is the largest side of the thumbnails, in pixels.
+const ThumbnailSize = 160
+
+var (
+	ErrNotAnImage = errors.New("attachments should be JPEG, PNG or GIF images")
+	ErrTooLarge   = errors.New("attachments should be up to 10 MB")
+)
+
+// Accepted are the content types of the images that can be attached.
+var Accepted = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
@@ -0,0 +1,9 @@
+package attachment
+
+type AttachmentsRepository interface {
+	Create(attachment *Attachment) error
+	Get(attachmentId int64) (Attachment, error)
+	// FindByItem returns the attachments of the item, oldest first.
+	FindByItem(itemId int64) ([]Attachment, error)
+	Delete(attachmentId int64) error
+}
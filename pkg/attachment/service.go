@@ -0,0 +1,81 @@
+package attachment
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"net/http"
+)
+
+// Service stores the files of the attachments along with their records.
+type Service struct {
+	Storage    Storage
+	Repository AttachmentsRepository
+}
+
+// Add attaches the image to the item, storing it with its thumbnail.
+func (s *Service) Add(itemId int64, uploaderId int64, fileName string, data []byte) (Attachment, error) {
+	if len(data) > MaxSize {
+		return Attachment{}, ErrTooLarge
+	}
+	contentType := http.DetectContentType(data)
+	if !Accepted[contentType] {
+		return Attachment{}, ErrNotAnImage
+	}
+	thumbnail, err := Thumbnail(bytes.NewReader(data), ThumbnailSize)
+	if err != nil {
+		return Attachment{}, err
+	}
+	key := NewKey()
+	a := Attachment{
+		ItemId:       itemId,
+		FileName:     fileName,
+		ContentType:  contentType,
+		StorageKey:   key,
+		ThumbnailKey: key + "-thumbnail",
+		UploadedById: &uploaderId,
+	}
+	if err := s.Storage.Save(a.StorageKey, bytes.NewReader(data)); err != nil {
+		return Attachment{}, err
+	}
+	if err := s.Storage.Save(a.ThumbnailKey, bytes.NewReader(thumbnail)); err != nil {
+		s.Storage.Delete(a.StorageKey)
+		return Attachment{}, err
+	}
+	if err := s.Repository.Create(&a); err != nil {
+		s.Storage.Delete(a.StorageKey)
+		s.Storage.Delete(a.ThumbnailKey)
+		return Attachment{}, err
+	}
+	return a, nil
+}
+
+// Open returns the image or its thumbnail, which is always a JPEG.
+func (s *Service) Open(a *Attachment, thumbnail bool) (io.ReadCloser, string, error) {
+	if thumbnail {
+		r, err := s.Storage.Open(a.ThumbnailKey)
+		return r, "image/jpeg", err
+	}
+	r, err := s.Storage.Open(a.StorageKey)
+	return r, a.ContentType, err
+}
+
+// Remove deletes the attachment. Its files are deleted after the record,
+// so a failure leaves at worst unreferenced files behind.
+func (s *Service) Remove(a *Attachment) error {
+	if err := s.Repository.Delete(a.AttachmentId); err != nil {
+		return err
+	}
+	s.DeleteFiles(a.StorageKey, a.ThumbnailKey)
+	return nil
+}
+
+// DeleteFiles deletes stored files whose records are already gone, such as
+// the ones of purged lists. Failures are only logged.
+func (s *Service) DeleteFiles(keys ...string) {
+	for _, key := range keys {
+		if err := s.Storage.Delete(key); err != nil {
+			log.Println("Error deleting attachment file", key, err)
+		}
+	}
+}
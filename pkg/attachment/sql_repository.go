@@ -0,0 +1,86 @@
+package attachment
+
+import (
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlAttachmentsRepository struct{}
+
+// Columns read by Scan, for a query on item_attachment aliased as a.
+const Columns = `a.attachmentId, a.itemId, a.fileName, a.contentType, a.storageKey, a.thumbnailKey, a.uploadedByLuserId, a.createdAt`
+
+type Scanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func Scan(row Scanner) (Attachment, error) {
+	a := Attachment{}
+	err := row.Scan(&a.AttachmentId, &a.ItemId, &a.FileName, &a.ContentType, &a.StorageKey, &a.ThumbnailKey, &a.UploadedById, &a.CreatedAt)
+	return a, err
+}
+
+// Create implements AttachmentsRepository.
+func (s *SqlAttachmentsRepository) Create(attachment *Attachment) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	attachment.CreatedAt = time.Now().UTC()
+	result, err := db.Exec(`
+  INSERT INTO item_attachment (itemId, fileName, contentType, storageKey, thumbnailKey, uploadedByLuserId, createdAt)
+  VALUES (?, ?, ?, ?, ?, ?, ?)
+  `, attachment.ItemId, attachment.FileName, attachment.ContentType, attachment.StorageKey, attachment.ThumbnailKey,
+		attachment.UploadedById, attachment.CreatedAt)
+	if err != nil {
+		return err
+	}
+	attachment.AttachmentId, err = result.LastInsertId()
+	return err
+}
+
+// Get implements AttachmentsRepository.
+func (s *SqlAttachmentsRepository) Get(attachmentId int64) (Attachment, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Attachment{}, err
+	}
+	defer db.Close()
+	return Scan(db.QueryRow(`SELECT `+Columns+` FROM item_attachment a WHERE a.attachmentId = ?`, attachmentId))
+}
+
+// FindByItem implements AttachmentsRepository.
+func (s *SqlAttachmentsRepository) FindByItem(itemId int64) ([]Attachment, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`SELECT `+Columns+` FROM item_attachment a WHERE a.itemId = ? ORDER BY a.attachmentId`, itemId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	attachments := make([]Attachment, 0)
+	for rows.Next() {
+		a, err := Scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		attachments = append(attachments, a)
+	}
+	return attachments, rows.Err()
+}
+
+// Delete implements AttachmentsRepository.
+func (s *SqlAttachmentsRepository) Delete(attachmentId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`DELETE FROM item_attachment WHERE attachmentId = ?`, attachmentId)
+	return err
+}
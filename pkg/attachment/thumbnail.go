@@ -0,0 +1,60 @@
+package attachment
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// Decoders of the accepted formats
+	_ "image/gif"
+	_ "image/png"
+)
+
+// Thumbnail scales the image down to fit in size x size pixels, encoded as JPEG.
+// Each pixel of the thumbnail averages the pixels of the image it covers, and
+// transparent pixels are laid over white, as JPEG has no transparency.
+func Thumbnail(r io.Reader, size int) ([]byte, error) {
+	src, _, err := image.Decode(r)
+	if err != nil {
+		return nil, ErrNotAnImage
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w == 0 || h == 0 {
+		return nil, ErrNotAnImage
+	}
+	tw, th := w, h
+	if w > size || h > size {
+		if w >= h {
+			tw, th = size, max(1, h*size/w)
+		} else {
+			tw, th = max(1, w*size/h), size
+		}
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		y0, y1 := bounds.Min.Y+y*h/th, bounds.Min.Y+(y+1)*h/th
+		for x := 0; x < tw; x++ {
+			x0, x1 := bounds.Min.X+x*w/tw, bounds.Min.X+(x+1)*w/tw
+			var sr, sg, sb, n uint64
+			for sy := y0; sy < max(y1, y0+1); sy++ {
+				for sx := x0; sx < max(x1, x0+1); sx++ {
+					r, g, b, a := src.At(sx, sy).RGBA()
+					// Colors are alpha premultiplied, white fills what is transparent
+					sr += uint64(r + 0xffff - a)
+					sg += uint64(g + 0xffff - a)
+					sb += uint64(b + 0xffff - a)
+					n++
+				}
+			}
+			dst.Set(x, y, color.RGBA64{R: uint16(sr / n), G: uint16(sg / n), B: uint16(sb / n), A: 0xffff})
+		}
+	}
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, dst, &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
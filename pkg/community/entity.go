@@ -20,3 +20,16 @@ type Member struct {
 	CommunityId int64
 	user.User
 }
+
+// IsMember tells whether the user created the community or was added to it.
+func (c *Community) IsMember(userId int64) bool {
+	if c.CreatedBy != nil && c.CreatedBy.Id == userId {
+		return true
+	}
+	for _, member := range c.Members {
+		if member.Id == userId {
+			return true
+		}
+	}
+	return false
+}
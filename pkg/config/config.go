@@ -4,6 +4,7 @@ import (
 	"flag"
 	"log"
 	"os"
+	"strings"
 	"time"
 )
 
@@ -25,6 +26,22 @@ type Config struct {
 	HotReload      bool
 	AppUrl         string
 	SmtpConfig
+	// How long before the due date of a list its members are reminded
+	ReminderOffsets  []time.Duration
+	ReminderInterval time.Duration
+	// How often recurring lists are checked for their next occurrence
+	RecurrenceInterval time.Duration
+	// How long deleted lists and groups stay in the trash before being purged
+	TrashRetention time.Duration
+	TrashInterval  time.Duration
+	// Directory where the images attached to items are stored
+	AttachmentsDir string
+	// Base URL of the API of the delivery provider, empty when lists can't be ordered
+	DeliveryUrl string
+	// Runs a fake delivery provider on a local port and orders from it, for development
+	DeliveryFake bool
+	// How often the status of the open delivery orders is checked
+	DeliveryInterval time.Duration
 }
 
 func ParseConfig() *Config {
@@ -42,6 +59,15 @@ func ParseConfig() *Config {
 	flag.StringVar(&config.Username, "smtp-username", "", "SMTP Username")
 	flag.BoolVar(&config.HotReload, "hot-reload", false, "If passed, will serve a websocket endpoint that identifies this run, allowing the client to restart")
 	flag.StringVar(&config.AppUrl, "app-url", "https://lists.vilmasoftware.com.br", "the URL of the app")
+	reminderOffsets := flag.String("reminder-offsets", "24h,1h", "Comma separated durations before the due date of a list to email reminders, empty to disable them")
+	flag.DurationVar(&config.ReminderInterval, "reminder-interval", time.Minute, "How often to check for reminders to send")
+	flag.DurationVar(&config.RecurrenceInterval, "recurrence-interval", time.Minute, "How often to check for recurring lists to start again")
+	flag.DurationVar(&config.TrashRetention, "trash-retention", 30*24*time.Hour, "How long deleted lists and groups are kept in the trash before being purged")
+	flag.StringVar(&config.AttachmentsDir, "attachments-dir", "./data/attachments", "Directory where the images attached to items are stored")
+	flag.DurationVar(&config.TrashInterval, "trash-interval", time.Hour, "How often to purge the trash of what is past the retention")
+	flag.StringVar(&config.DeliveryUrl, "delivery-url", "", "Base URL of the API of the delivery provider lists are ordered from, empty to disable delivery orders")
+	flag.BoolVar(&config.DeliveryFake, "delivery-fake", false, "If passed, orders from a fake delivery provider served on a local port")
+	flag.DurationVar(&config.DeliveryInterval, "delivery-interval", 30*time.Second, "How often to check the status of the open delivery orders")
 
 	flag.Parse()
 	if config.DatabaseUrl == "" {
@@ -50,6 +76,16 @@ func ParseConfig() *Config {
 	if config.Listen == "" {
 		panic("-listen is required")
 	}
+	for _, offset := range strings.Split(*reminderOffsets, ",") {
+		if strings.TrimSpace(offset) == "" {
+			continue
+		}
+		d, err := time.ParseDuration(strings.TrimSpace(offset))
+		if err != nil || d < 0 {
+			log.Fatalf("Invalid -reminder-offsets %q: %v", *reminderOffsets, err)
+		}
+		config.ReminderOffsets = append(config.ReminderOffsets, d)
+	}
 	if config.UseTls {
 		_, err := os.Stat(config.PrivateKey)
 		if err != nil {
@@ -0,0 +1,98 @@
+// Package delivery orders the items still to buy of a list from a delivery provider and
+// follows the orders until they are delivered.
+package delivery
+
+import (
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+type Status string
+
+const (
+	StatusPending        Status = "pending"
+	StatusConfirmed      Status = "confirmed"
+	StatusOutForDelivery Status = "out_for_delivery"
+	StatusDelivered      Status = "delivered"
+	StatusCancelled      Status = "cancelled"
+)
+
+// IsFinal tells whether the status of the order won't change anymore.
+func (s Status) IsFinal() bool {
+	return s == StatusDelivered || s == StatusCancelled
+}
+
+func (s Status) Label() string {
+	switch s {
+	case StatusPending:
+		return "Waiting for the store"
+	case StatusConfirmed:
+		return "Being prepared"
+	case StatusOutForDelivery:
+		return "Out for delivery"
+	case StatusDelivered:
+		return "Delivered"
+	case StatusCancelled:
+		return "Cancelled"
+	}
+	return string(s)
+}
+
+// Product is what a provider sells, in packages of a size, e.g. milk in bottles of 1 l.
+type Product struct {
+	Id       string
+	Name     string
+	Size     units.Quantity
+	Price    float64
+	Currency string
+}
+
+// OrderLine is an item of the list with the product it was mapped to, nil when the provider does not sell it.
+type OrderLine struct {
+	ItemId      int64
+	Description string
+	Quantity    units.Quantity
+	Product     *Product
+	// How many packages of the product make up the quantity of the item
+	Packages int
+}
+
+func (l OrderLine) Total() float64 {
+	if l.Product == nil {
+		return 0
+	}
+	return units.Round(l.Product.Price * float64(l.Packages))
+}
+
+type Order struct {
+	Id     int64
+	ListId int64
+	// Name of the provider the order was submitted to
+	Provider string
+	// Id of the order at the provider
+	ExternalId string
+	Status     Status
+	// Only the lines mapped to products are ordered
+	Lines     []OrderLine
+	Total     float64
+	Currency  string
+	CreatorId int64
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Submission is what the provider answers when an order is submitted.
+type Submission struct {
+	ExternalId string
+	Status     Status
+	Total      float64
+	Currency   string
+}
+
+var (
+	ErrNotFound       = errors.New("order not found")
+	ErrNothingToOrder = errors.New("none of the items to buy are sold by the delivery provider")
+	ErrOrderOpen      = errors.New("the list already has an order waiting to be delivered")
+)
@@ -0,0 +1,170 @@
+package delivery
+
+import (
+	"encoding/json"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// FakeProvider serves the API of HttpProvider with a few groceries, for development and trying
+// orders out. Its orders move to the next status every step until they are delivered.
+type FakeProvider struct {
+	step     time.Duration
+	products []fakeProduct
+	mu       sync.Mutex
+	orders   map[string]time.Time
+	lastId   int
+}
+
+type fakeProduct struct {
+	apiProduct
+	// Descriptions of items the product is matched to
+	keywords []string
+}
+
+func NewFakeProvider(step time.Duration) *FakeProvider {
+	product := func(id, name string, size float64, unit string, price float64, keywords ...string) fakeProduct {
+		return fakeProduct{
+			apiProduct: apiProduct{Id: id, Name: name, Size: size, Unit: unit, Price: price, Currency: "USD"},
+			keywords:   append(keywords, strings.ToLower(name)),
+		}
+	}
+	return &FakeProvider{
+		step: step,
+		products: []fakeProduct{
+			product("milk-1l", "Milk", 1, "l", 1.2, "leite"),
+			product("eggs-12", "Eggs", 1, "dozen", 3.5, "egg", "ovos", "ovo"),
+			product("flour-1kg", "Flour", 1, "kg", 1.1, "farinha"),
+			product("rice-1kg", "Rice", 1, "kg", 2, "arroz"),
+			product("sugar-1kg", "Sugar", 1, "kg", 1, "açúcar"),
+			product("butter-200g", "Butter", 200, "g", 2.8, "manteiga"),
+			product("cheese-200g", "Cheese", 200, "g", 3, "queijo"),
+			product("coffee-500g", "Coffee", 500, "g", 6, "café"),
+			product("bread", "Bread", 1, "loaf", 2.5, "pão"),
+			product("banana", "Banana", 1, "unit", 0.3, "bananas"),
+			product("apple", "Apple", 1, "unit", 0.5, "apples", "maçã"),
+			product("tomatoes-1kg", "Tomatoes", 1, "kg", 2.5, "tomato", "tomate"),
+			product("chicken-1kg", "Chicken breast", 1, "kg", 8, "chicken", "frango"),
+		},
+		orders: make(map[string]time.Time),
+	}
+}
+
+// ServeFake serves a fake provider on the address, such as "127.0.0.1:0" for any free port, returning its base URL.
+func ServeFake(address string, step time.Duration) (string, error) {
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return "", err
+	}
+	go http.Serve(listener, NewFakeProvider(step))
+	return "http://" + listener.Addr().String(), nil
+}
+
+func (f *FakeProvider) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodPost && r.URL.Path == "/match":
+		f.match(w, r)
+	case r.Method == http.MethodPost && r.URL.Path == "/orders":
+		f.submit(w, r)
+	case r.Method == http.MethodGet && strings.HasPrefix(r.URL.Path, "/orders/"):
+		f.status(w, strings.TrimPrefix(r.URL.Path, "/orders/"))
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func (f *FakeProvider) match(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		Items []apiItem `json:"items"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	response := struct {
+		Lines []apiLine `json:"lines"`
+	}{Lines: make([]apiLine, 0, len(request.Items))}
+	for _, item := range request.Items {
+		line := apiLine{}
+		if product := f.find(item.Description); product != nil {
+			line.Product = &product.apiProduct
+			line.Packages = packages(item, product.apiProduct)
+		}
+		response.Lines = append(response.Lines, line)
+	}
+	json.NewEncoder(w).Encode(response)
+}
+
+func (f *FakeProvider) find(description string) *fakeProduct {
+	key := strings.ToLower(strings.TrimSpace(description))
+	for i, product := range f.products {
+		for _, keyword := range product.keywords {
+			if key == keyword || strings.TrimSuffix(key, "s") == keyword {
+				return &f.products[i]
+			}
+		}
+	}
+	return nil
+}
+
+// packages returns how many packages of the product hold the quantity of the item, or the quantity
+// itself as a number of packages when it is in a unit that can't be converted to the product's.
+func packages(item apiItem, product apiProduct) int {
+	amount := item.Quantity
+	if converted, err := units.Convert(item.Quantity, item.Unit, product.Unit); err == nil {
+		amount = converted / product.Size
+	}
+	return max(1, int(math.Ceil(amount)))
+}
+
+func (f *FakeProvider) submit(w http.ResponseWriter, r *http.Request) {
+	var request apiOrder
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil || len(request.Lines) == 0 {
+		http.Error(w, "the order has no lines", http.StatusBadRequest)
+		return
+	}
+	response := apiOrder{Status: StatusPending, Currency: "USD"}
+	for _, line := range request.Lines {
+		var product *apiProduct
+		for i := range f.products {
+			if f.products[i].Id == line.ProductId {
+				product = &f.products[i].apiProduct
+			}
+		}
+		if product == nil || line.Packages < 1 {
+			http.Error(w, "unknown product "+line.ProductId, http.StatusBadRequest)
+			return
+		}
+		response.Total += product.Price * float64(line.Packages)
+	}
+	response.Total = units.Round(response.Total)
+	f.mu.Lock()
+	f.lastId++
+	response.Id = strconv.Itoa(f.lastId)
+	f.orders[response.Id] = time.Now()
+	f.mu.Unlock()
+	json.NewEncoder(w).Encode(response)
+}
+
+func (f *FakeProvider) status(w http.ResponseWriter, id string) {
+	f.mu.Lock()
+	submittedAt, ok := f.orders[id]
+	f.mu.Unlock()
+	if !ok {
+		http.Error(w, "order not found", http.StatusNotFound)
+		return
+	}
+	statuses := []Status{StatusPending, StatusConfirmed, StatusOutForDelivery, StatusDelivered}
+	steps := len(statuses) - 1
+	if f.step > 0 {
+		steps = min(steps, int(time.Since(submittedAt)/f.step))
+	}
+	json.NewEncoder(w).Encode(apiOrder{Id: id, Status: statuses[steps]})
+}
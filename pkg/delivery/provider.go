@@ -0,0 +1,159 @@
+package delivery
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// DeliveryProvider is a store that delivers groceries.
+type DeliveryProvider interface {
+	Name() string
+	// MatchProducts maps the items to the products of the provider, in the same order, leaving
+	// the product of the lines it does not sell nil.
+	MatchProducts(items []OrderLine) ([]OrderLine, error)
+	// SubmitOrder orders the lines, which must all have a product.
+	SubmitOrder(lines []OrderLine) (Submission, error)
+	// OrderStatus returns the status of an order by its id at the provider.
+	OrderStatus(externalId string) (Status, error)
+}
+
+// HttpProvider is a provider with a JSON API:
+//
+//	POST /match  {"items": [{"description": "milk", "quantity": 2, "unit": "l"}]}
+//	             -> {"lines": [{"product": {"id": "milk", "name": "Milk", "size": 1, "unit": "l", "price": 1.2, "currency": "USD"}, "packages": 2}]}
+//	POST /orders {"lines": [{"productId": "milk", "packages": 2}]}
+//	             -> {"id": "1", "status": "pending", "total": 2.4, "currency": "USD"}
+//	GET /orders/{id} -> {"id": "1", "status": "confirmed"}
+type HttpProvider struct {
+	BaseUrl string
+	Client  *http.Client
+}
+
+func NewHttpProvider(baseUrl string) *HttpProvider {
+	return &HttpProvider{BaseUrl: strings.TrimSuffix(baseUrl, "/"), Client: &http.Client{Timeout: 10 * time.Second}}
+}
+
+type apiItem struct {
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	Unit        string  `json:"unit"`
+}
+
+type apiProduct struct {
+	Id       string  `json:"id"`
+	Name     string  `json:"name"`
+	Size     float64 `json:"size"`
+	Unit     string  `json:"unit"`
+	Price    float64 `json:"price"`
+	Currency string  `json:"currency"`
+}
+
+type apiLine struct {
+	Product   *apiProduct `json:"product,omitempty"`
+	ProductId string      `json:"productId,omitempty"`
+	Packages  int         `json:"packages"`
+}
+
+type apiOrder struct {
+	Lines    []apiLine `json:"lines,omitempty"`
+	Id       string    `json:"id,omitempty"`
+	Status   Status    `json:"status,omitempty"`
+	Total    float64   `json:"total,omitempty"`
+	Currency string    `json:"currency,omitempty"`
+}
+
+// Name implements DeliveryProvider.
+func (p *HttpProvider) Name() string {
+	if u, err := url.Parse(p.BaseUrl); err == nil && u.Host != "" {
+		return u.Host
+	}
+	return p.BaseUrl
+}
+
+// MatchProducts implements DeliveryProvider.
+func (p *HttpProvider) MatchProducts(items []OrderLine) ([]OrderLine, error) {
+	request := struct {
+		Items []apiItem `json:"items"`
+	}{Items: make([]apiItem, 0, len(items))}
+	for _, item := range items {
+		request.Items = append(request.Items, apiItem{Description: item.Description, Quantity: item.Quantity.Amount, Unit: item.Quantity.Unit})
+	}
+	var response struct {
+		Lines []apiLine `json:"lines"`
+	}
+	if err := p.do(http.MethodPost, "/match", request, &response); err != nil {
+		return nil, err
+	}
+	if len(response.Lines) != len(items) {
+		return nil, fmt.Errorf("%s matched %d items out of %d", p.Name(), len(response.Lines), len(items))
+	}
+	lines := make([]OrderLine, 0, len(items))
+	for i, item := range items {
+		line := response.Lines[i]
+		item.Product, item.Packages = nil, 0
+		if line.Product != nil {
+			item.Product = &Product{
+				Id:       line.Product.Id,
+				Name:     line.Product.Name,
+				Size:     units.Quantity{Amount: line.Product.Size, Unit: line.Product.Unit},
+				Price:    line.Product.Price,
+				Currency: line.Product.Currency,
+			}
+			item.Packages = line.Packages
+		}
+		lines = append(lines, item)
+	}
+	return lines, nil
+}
+
+// SubmitOrder implements DeliveryProvider.
+func (p *HttpProvider) SubmitOrder(lines []OrderLine) (Submission, error) {
+	request := apiOrder{Lines: make([]apiLine, 0, len(lines))}
+	for _, line := range lines {
+		request.Lines = append(request.Lines, apiLine{ProductId: line.Product.Id, Packages: line.Packages})
+	}
+	var response apiOrder
+	if err := p.do(http.MethodPost, "/orders", request, &response); err != nil {
+		return Submission{}, err
+	}
+	return Submission{ExternalId: response.Id, Status: response.Status, Total: response.Total, Currency: response.Currency}, nil
+}
+
+// OrderStatus implements DeliveryProvider.
+func (p *HttpProvider) OrderStatus(externalId string) (Status, error) {
+	var response apiOrder
+	if err := p.do(http.MethodGet, "/orders/"+url.PathEscape(externalId), nil, &response); err != nil {
+		return "", err
+	}
+	return response.Status, nil
+}
+
+func (p *HttpProvider) do(method string, path string, body any, response any) error {
+	var payload bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&payload).Encode(body); err != nil {
+			return err
+		}
+	}
+	req, err := http.NewRequest(method, p.BaseUrl+path, &payload)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	res, err := p.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s %s answered %s", method, path, res.Status)
+	}
+	return json.NewDecoder(res.Body).Decode(response)
+}
@@ -0,0 +1,132 @@
+package delivery
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// newTestProvider serves a fake provider whose orders move every step, stopping it with the test.
+func newTestProvider(t *testing.T, step time.Duration) *HttpProvider {
+	t.Helper()
+	server := httptest.NewServer(NewFakeProvider(step))
+	t.Cleanup(server.Close)
+	return NewHttpProvider(server.URL + "/")
+}
+
+func TestHttpProviderName(t *testing.T) {
+	p := NewHttpProvider("http://store.example.com:8080/")
+	if got := p.Name(); got != "store.example.com:8080" {
+		t.Errorf("Name() = %q, want the host of the base URL", got)
+	}
+}
+
+func TestHttpProviderMatchProducts(t *testing.T) {
+	p := newTestProvider(t, 0)
+	items := []OrderLine{
+		{ItemId: 1, Description: "Milk", Quantity: units.Quantity{Amount: 2, Unit: "l"}},
+		{ItemId: 2, Description: "tomatoes", Quantity: units.Quantity{Amount: 500, Unit: "g"}},
+		{ItemId: 3, Description: "arroz", Quantity: units.Quantity{Amount: 3, Unit: "kg"}},
+		{ItemId: 4, Description: "caviar", Quantity: units.Quantity{Amount: 1}},
+		{ItemId: 5, Description: "bananas", Quantity: units.Quantity{Amount: 6}},
+	}
+	lines, err := p.MatchProducts(items)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct {
+		productId string
+		packages  int
+	}{
+		{"milk-1l", 2},
+		{"tomatoes-1kg", 1},
+		{"rice-1kg", 3},
+		{"", 0},
+		{"banana", 6},
+	}
+	if len(lines) != len(want) {
+		t.Fatalf("got %d lines, want %d", len(lines), len(want))
+	}
+	for i, line := range lines {
+		if line.ItemId != items[i].ItemId || line.Description != items[i].Description {
+			t.Errorf("line %d is for item %d %q, want the item in the same position", i, line.ItemId, line.Description)
+		}
+		productId := ""
+		if line.Product != nil {
+			productId = line.Product.Id
+		}
+		if productId != want[i].productId || line.Packages != want[i].packages {
+			t.Errorf("%q matched %q x %d, want %q x %d", line.Description, productId, line.Packages, want[i].productId, want[i].packages)
+		}
+	}
+	if milk := lines[0].Product; milk.Size != (units.Quantity{Amount: 1, Unit: "l"}) || milk.Price != 1.2 || milk.Currency != "USD" {
+		t.Errorf("milk product = %+v, want 1 l at 1.2 USD", milk)
+	}
+}
+
+func TestHttpProviderSubmitOrder(t *testing.T) {
+	p := newTestProvider(t, 0)
+	lines, err := p.MatchProducts([]OrderLine{
+		{Description: "milk", Quantity: units.Quantity{Amount: 2, Unit: "l"}},
+		{Description: "bread", Quantity: units.Quantity{Amount: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	submission, err := p.SubmitOrder(lines)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if submission.ExternalId == "" || submission.Status != StatusPending {
+		t.Errorf("submission = %+v, want an id and the pending status", submission)
+	}
+	if submission.Total != 4.9 || submission.Currency != "USD" {
+		t.Errorf("total = %v %s, want 4.9 USD", submission.Total, submission.Currency)
+	}
+}
+
+func TestHttpProviderSubmitOrderRejected(t *testing.T) {
+	p := newTestProvider(t, 0)
+	_, err := p.SubmitOrder([]OrderLine{{Product: &Product{Id: "caviar"}, Packages: 1}})
+	if err == nil || !strings.Contains(err.Error(), "400") {
+		t.Errorf("SubmitOrder of an unknown product = %v, want a 400 error", err)
+	}
+}
+
+func TestHttpProviderOrderStatus(t *testing.T) {
+	lines := []OrderLine{{Product: &Product{Id: "milk-1l"}, Packages: 1}}
+	tests := []struct {
+		name string
+		step time.Duration
+		want Status
+	}{
+		{"right after submitting", time.Hour, StatusPending},
+		{"after every step", 0, StatusDelivered},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestProvider(t, tt.step)
+			submission, err := p.SubmitOrder(lines)
+			if err != nil {
+				t.Fatal(err)
+			}
+			status, err := p.OrderStatus(submission.ExternalId)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != tt.want {
+				t.Errorf("OrderStatus() = %q, want %q", status, tt.want)
+			}
+		})
+	}
+}
+
+func TestHttpProviderOrderStatusUnknown(t *testing.T) {
+	p := newTestProvider(t, 0)
+	if _, err := p.OrderStatus("42"); err == nil {
+		t.Error("OrderStatus of an unknown order should fail")
+	}
+}
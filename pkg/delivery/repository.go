@@ -0,0 +1,11 @@
+package delivery
+
+type OrdersRepository interface {
+	// Save inserts the order with its lines, writing its id back.
+	Save(order *Order) error
+	UpdateStatus(orderId int64, status Status) error
+	// FindByList returns the orders of the list with their lines, the most recent first.
+	FindByList(listId int64) ([]Order, error)
+	// FindOpen returns the orders that were not delivered or cancelled yet, without their lines.
+	FindOpen() ([]Order, error)
+}
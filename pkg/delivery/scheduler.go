@@ -0,0 +1,21 @@
+package delivery
+
+import "time"
+
+// Scheduler polls the status of the open orders.
+type Scheduler struct {
+	service  *Service
+	interval time.Duration
+}
+
+func NewScheduler(service *Service, interval time.Duration) *Scheduler {
+	return &Scheduler{service: service, interval: interval}
+}
+
+func (s *Scheduler) Run() {
+	ticker := time.NewTicker(s.interval)
+	for {
+		s.service.Poll()
+		<-ticker.C
+	}
+}
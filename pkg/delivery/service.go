@@ -0,0 +1,90 @@
+package delivery
+
+import (
+	"log"
+
+	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+type Service struct {
+	Provider DeliveryProvider
+	Orders   OrdersRepository
+}
+
+// Preview maps the items of the list still to buy to the products of the provider.
+func (s *Service) Preview(l *list.List) ([]OrderLine, error) {
+	items := make([]OrderLine, 0)
+	for _, group := range l.Groups {
+		for _, item := range group.Items {
+			if item.Checked != 0 || item.IsPlaceholder() {
+				continue
+			}
+			items = append(items, OrderLine{ItemId: item.Id, Description: item.Description, Quantity: units.Quantity{Amount: item.Quantity, Unit: item.Unit}})
+		}
+	}
+	if len(items) == 0 {
+		return items, nil
+	}
+	return s.Provider.MatchProducts(items)
+}
+
+// Submit orders the items of the list still to buy that the provider sells. A list has one open order at a time.
+func (s *Service) Submit(l *list.List, creatorId int64) (*Order, error) {
+	orders, err := s.Orders.FindByList(l.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, order := range orders {
+		if !order.Status.IsFinal() {
+			return nil, ErrOrderOpen
+		}
+	}
+	preview, err := s.Preview(l)
+	if err != nil {
+		return nil, err
+	}
+	order := &Order{ListId: l.Id, Provider: s.Provider.Name(), CreatorId: creatorId}
+	for _, line := range preview {
+		if line.Product != nil && line.Packages > 0 {
+			order.Lines = append(order.Lines, line)
+		}
+	}
+	if len(order.Lines) == 0 {
+		return nil, ErrNothingToOrder
+	}
+	submission, err := s.Provider.SubmitOrder(order.Lines)
+	if err != nil {
+		return nil, err
+	}
+	order.ExternalId = submission.ExternalId
+	order.Status = submission.Status
+	order.Total = submission.Total
+	order.Currency = submission.Currency
+	if err := s.Orders.Save(order); err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// Poll asks the provider for the status of the open orders, saving the ones that changed.
+func (s *Service) Poll() {
+	orders, err := s.Orders.FindOpen()
+	if err != nil {
+		log.Println("Error finding open delivery orders", err)
+		return
+	}
+	for _, order := range orders {
+		status, err := s.Provider.OrderStatus(order.ExternalId)
+		if err != nil {
+			log.Println("Error checking the status of delivery order", order.Id, err)
+			continue
+		}
+		if status == order.Status {
+			continue
+		}
+		if err := s.Orders.UpdateStatus(order.Id, status); err != nil {
+			log.Println("Error updating the status of delivery order", order.Id, err)
+		}
+	}
+}
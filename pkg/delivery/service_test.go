@@ -0,0 +1,148 @@
+package delivery
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/list"
+)
+
+// memoryOrders keeps the orders in memory, counting the status updates.
+type memoryOrders struct {
+	orders  []Order
+	updates int
+}
+
+func (m *memoryOrders) Save(order *Order) error {
+	order.Id = int64(len(m.orders) + 1)
+	m.orders = append(m.orders, *order)
+	return nil
+}
+
+func (m *memoryOrders) UpdateStatus(orderId int64, status Status) error {
+	for i := range m.orders {
+		if m.orders[i].Id == orderId {
+			m.orders[i].Status = status
+			m.updates++
+			return nil
+		}
+	}
+	return ErrNotFound
+}
+
+func (m *memoryOrders) FindByList(listId int64) ([]Order, error) {
+	found := make([]Order, 0)
+	for _, order := range m.orders {
+		if order.ListId == listId {
+			found = append(found, order)
+		}
+	}
+	return found, nil
+}
+
+func (m *memoryOrders) FindOpen() ([]Order, error) {
+	found := make([]Order, 0)
+	for _, order := range m.orders {
+		if !order.Status.IsFinal() {
+			found = append(found, order)
+		}
+	}
+	return found, nil
+}
+
+func newTestService(t *testing.T, step time.Duration) (*Service, *memoryOrders) {
+	t.Helper()
+	orders := &memoryOrders{}
+	return &Service{Provider: newTestProvider(t, step), Orders: orders}, orders
+}
+
+func shoppingList(id int64, items ...*list.Item) *list.List {
+	return &list.List{Id: id, Groups: []*list.Group{{GroupId: 1, ListId: id, Name: "Groceries", Items: items}}}
+}
+
+func TestServiceSubmit(t *testing.T) {
+	s, orders := newTestService(t, time.Hour)
+	l := shoppingList(7,
+		&list.Item{Id: 1, Description: "milk", Quantity: 2, Unit: "l"},
+		&list.Item{Id: 2, Description: "rice", Quantity: 1, Unit: "kg", Checked: 1},
+		&list.Item{Id: 3, Description: "caviar", Quantity: 1},
+		&list.Item{Id: 4, Description: "New Item", Quantity: 1},
+		&list.Item{Id: 5, Description: "ovos", Quantity: 1, Unit: "dozen"},
+	)
+	order, err := s.Submit(l, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if order.Id == 0 || len(orders.orders) != 1 {
+		t.Fatalf("the order was not saved: %+v", orders.orders)
+	}
+	if order.ListId != 7 || order.CreatorId != 3 || order.Provider != s.Provider.Name() {
+		t.Errorf("order = %+v, want list 7 ordered by user 3 from %s", order, s.Provider.Name())
+	}
+	if order.ExternalId == "" || order.Status != StatusPending {
+		t.Errorf("order has external id %q and status %q, want the ones of the provider", order.ExternalId, order.Status)
+	}
+	// Checked, placeholder and unsold items are left out
+	if len(order.Lines) != 2 || order.Lines[0].ItemId != 1 || order.Lines[1].ItemId != 5 {
+		t.Fatalf("order lines = %+v, want the milk and the eggs", order.Lines)
+	}
+	if order.Total != 5.9 || order.Currency != "USD" {
+		t.Errorf("total = %v %s, want 5.9 USD", order.Total, order.Currency)
+	}
+}
+
+func TestServiceSubmitOpenOrder(t *testing.T) {
+	s, _ := newTestService(t, time.Hour)
+	l := shoppingList(7, &list.Item{Id: 1, Description: "milk", Quantity: 1, Unit: "l"})
+	if _, err := s.Submit(l, 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Submit(l, 3); !errors.Is(err, ErrOrderOpen) {
+		t.Errorf("second Submit() = %v, want %v", err, ErrOrderOpen)
+	}
+}
+
+func TestServiceSubmitNothingToOrder(t *testing.T) {
+	s, orders := newTestService(t, time.Hour)
+	l := shoppingList(7,
+		&list.Item{Id: 1, Description: "caviar", Quantity: 1},
+		&list.Item{Id: 2, Description: "milk", Quantity: 1, Unit: "l", Checked: 1},
+	)
+	if _, err := s.Submit(l, 3); !errors.Is(err, ErrNothingToOrder) {
+		t.Errorf("Submit() = %v, want %v", err, ErrNothingToOrder)
+	}
+	if len(orders.orders) != 0 {
+		t.Errorf("nothing should be saved, got %+v", orders.orders)
+	}
+}
+
+func TestServicePoll(t *testing.T) {
+	tests := []struct {
+		name        string
+		step        time.Duration
+		wantStatus  Status
+		wantUpdates int
+	}{
+		{"status unchanged", time.Hour, StatusPending, 0},
+		{"status changed", 0, StatusDelivered, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, orders := newTestService(t, tt.step)
+			if _, err := s.Submit(shoppingList(7, &list.Item{Id: 1, Description: "bread", Quantity: 1}), 3); err != nil {
+				t.Fatal(err)
+			}
+			s.Poll()
+			if orders.orders[0].Status != tt.wantStatus || orders.updates != tt.wantUpdates {
+				t.Errorf("after polling the status is %q with %d updates, want %q with %d",
+					orders.orders[0].Status, orders.updates, tt.wantStatus, tt.wantUpdates)
+			}
+			// Delivered orders are not polled anymore
+			s.Poll()
+			if orders.updates != tt.wantUpdates {
+				t.Errorf("polling again made %d updates, want %d", orders.updates, tt.wantUpdates)
+			}
+		})
+	}
+}
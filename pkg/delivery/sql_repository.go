@@ -0,0 +1,160 @@
+package delivery
+
+import (
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlOrdersRepository struct{}
+
+const orderColumns = `orderId, listId, provider, externalId, status, total, currency, creatorLuserId, createdAt, updatedAt`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanOrder(row scanner) (Order, error) {
+	o := Order{}
+	err := row.Scan(&o.Id, &o.ListId, &o.Provider, &o.ExternalId, &o.Status, &o.Total, &o.Currency, &o.CreatorId, &o.CreatedAt, &o.UpdatedAt)
+	return o, err
+}
+
+// Save implements OrdersRepository.
+func (s *SqlOrdersRepository) Save(order *Order) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	order.CreatedAt = time.Now().UTC()
+	order.UpdatedAt = order.CreatedAt
+	result, err := tx.Exec(`
+    INSERT INTO delivery_order (listId, provider, externalId, status, total, currency, creatorLuserId, createdAt, updatedAt)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+  `, order.ListId, order.Provider, order.ExternalId, order.Status, order.Total, order.Currency, order.CreatorId, order.CreatedAt, order.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	if order.Id, err = result.LastInsertId(); err != nil {
+		return err
+	}
+	for _, line := range order.Lines {
+		_, err := tx.Exec(`
+      INSERT INTO delivery_order_line (orderId, itemId, description, quantity, unit, productId, productName, packages, price)
+      VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+    `, order.Id, line.ItemId, line.Description, line.Quantity.Amount, line.Quantity.Unit, line.Product.Id, line.Product.Name,
+			line.Packages, line.Product.Price)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// UpdateStatus implements OrdersRepository.
+func (s *SqlOrdersRepository) UpdateStatus(orderId int64, status Status) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`UPDATE delivery_order SET status = ?, updatedAt = ? WHERE orderId = ?`, status, time.Now().UTC(), orderId)
+	return err
+}
+
+// FindByList implements OrdersRepository.
+func (s *SqlOrdersRepository) FindByList(listId int64) ([]Order, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT `+orderColumns+`
+    FROM delivery_order
+    WHERE listId = ?
+    ORDER BY createdAt DESC, orderId DESC
+  `, listId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orders := make([]Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range orders {
+		if orders[i].Lines, err = getLines(db, orders[i].Id, orders[i].Currency); err != nil {
+			return nil, err
+		}
+	}
+	return orders, nil
+}
+
+func getLines(tx infra.Queryable, orderId int64, currency string) ([]OrderLine, error) {
+	rows, err := tx.Query(`
+    SELECT itemId, description, quantity, unit, productId, productName, packages, price
+    FROM delivery_order_line
+    WHERE orderId = ?
+    ORDER BY lineId
+  `, orderId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	lines := make([]OrderLine, 0)
+	for rows.Next() {
+		l := OrderLine{Product: &Product{Currency: currency}}
+		var itemId *int64
+		if err := rows.Scan(&itemId, &l.Description, &l.Quantity.Amount, &l.Quantity.Unit, &l.Product.Id, &l.Product.Name,
+			&l.Packages, &l.Product.Price); err != nil {
+			return nil, err
+		}
+		if itemId != nil {
+			l.ItemId = *itemId
+		}
+		lines = append(lines, l)
+	}
+	return lines, rows.Err()
+}
+
+// FindOpen implements OrdersRepository.
+func (s *SqlOrdersRepository) FindOpen() ([]Order, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT `+orderColumns+`
+    FROM delivery_order
+    WHERE status NOT IN (?, ?)
+    ORDER BY orderId
+  `, StatusDelivered, StatusCancelled)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	orders := make([]Order, 0)
+	for rows.Next() {
+		o, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, o)
+	}
+	return orders, rows.Err()
+}
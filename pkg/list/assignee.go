@@ -0,0 +1,51 @@
+package list
+
+import "vilmasoftware.com/colablists/pkg/user"
+
+// Members are the users items of the list can be assigned to: its creator and colaborators.
+func (l *List) Members() []user.User {
+	members := make([]user.User, 0, len(l.Colaborators)+1)
+	if l.Creator.Username != "" {
+		members = append(members, l.Creator)
+	}
+	for _, colaborator := range l.Colaborators {
+		if colaborator.Id != l.Creator.Id {
+			members = append(members, colaborator)
+		}
+	}
+	return members
+}
+
+func (l *List) IsMember(userId int64) bool {
+	if l.Creator.Id == userId {
+		return true
+	}
+	for _, colaborator := range l.Colaborators {
+		if colaborator.Id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+type AssignedItem struct {
+	ListId    int64
+	ListTitle string
+	GroupName string
+	Item      *Item
+}
+
+// ItemsAssignedTo collects the items of the lists assigned to the user.
+func ItemsAssignedTo(lists []List, userId int64) []AssignedItem {
+	items := make([]AssignedItem, 0)
+	for _, l := range lists {
+		for _, group := range l.Groups {
+			for _, item := range group.Items {
+				if item.AssigneeId != nil && *item.AssigneeId == userId {
+					items = append(items, AssignedItem{ListId: l.Id, ListTitle: l.Title, GroupName: group.Name, Item: item})
+				}
+			}
+		}
+	}
+	return items
+}
@@ -7,6 +7,19 @@ type ListCreationParams struct {
 	CommunityId *int64
 }
 
+// DuplicateParams describe a copy of the list SourceId, e.g. a template or a list created from one.
+type DuplicateParams struct {
+	SourceId    int64
+	Title       string
+	Description string
+	CreatorId   int64
+	CommunityId *int64
+	IsTemplate  bool
+	// Copies the colaborators of the source too, else only the creator is a member
+	KeepColaborators bool
+	CopyOptions
+}
+
 const (
 	AddItem    = iota
 	RemoveItem = iota
@@ -17,7 +30,8 @@ type ItemUpdateParams struct {
 	Id          int
 	Order       int
 	Description string
-	Quantity    int
+	Quantity    float64
+	Unit        string
 	GroupName   string
 	Operation   int
 }
@@ -2,10 +2,14 @@ package list
 
 import (
 	"fmt"
+	"maps"
+	"slices"
 	"strconv"
 	"time"
 
+	"vilmasoftware.com/colablists/pkg/attachment"
 	"vilmasoftware.com/colablists/pkg/community"
+	"vilmasoftware.com/colablists/pkg/units"
 	"vilmasoftware.com/colablists/pkg/user"
 )
 
@@ -19,6 +23,106 @@ type List struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	Community    *community.Community
+	// Maximum the list is expected to cost, in Currency
+	Budget   *float64
+	Currency string
+	// When the list should be done, in UTC
+	DueAt *time.Time
+	// Rule parsed by the recurrence package, empty when the list does not recur
+	Recurrence       string
+	RecurrenceMode   string
+	NextOccurrenceAt *time.Time
+	// First list of the recurring series, nil for the first one itself
+	SeriesId   *int64
+	ArchivedAt *time.Time
+	// Templates are copied into new lists and are not listed with the others
+	IsTemplate bool
+	// When the list was moved to the trash, in UTC
+	DeletedAt *time.Time
+	// Deleted groups, kept until restored or purged
+	TrashedGroups []*Group
+	// Lowercase labels given by the members, see NormalizeTags
+	Tags []string
+	// Roles of the colaborators by their id, see RoleOf
+	ColaboratorRoles map[int64]Role
+}
+
+const (
+	// Each occurrence unchecks the items of the list
+	RecurrenceReset = "reset"
+	// Each occurrence archives the list and continues in a fresh copy
+	RecurrenceSpawn = "spawn"
+)
+
+// Series identifies the occurrences of a recurring list.
+func (l *List) Series() int64 {
+	if l.SeriesId != nil {
+		return *l.SeriesId
+	}
+	return l.Id
+}
+
+type CopyOptions struct {
+	Uncheck         bool
+	ResetQuantities bool
+	// Assignees are only meaningful among the members of the list
+	KeepAssignees bool
+}
+
+// CopyGroups deep copies the groups and items.
+// Ids are kept, as they are only meaningful until the list is saved.
+func (l *List) CopyGroups(options CopyOptions) []*Group {
+	groups := make([]*Group, 0, len(l.Groups))
+	for _, group := range l.Groups {
+		g := *group
+		g.Items = make([]*Item, 0, len(group.Items))
+		for _, item := range group.Items {
+			i := *item
+			if options.Uncheck {
+				i.Checked = 0
+			}
+			if options.ResetQuantities {
+				i.Quantity = 1
+			}
+			if !options.KeepAssignees {
+				i.AssigneeId = nil
+			}
+			// Attachments belong to the saved item
+			i.Attachments = nil
+			g.Items = append(g.Items, &i)
+		}
+		groups = append(groups, &g)
+	}
+	return groups
+}
+
+// Clone deep copies the list, so the copy can be read while the original is changed.
+func (l *List) Clone() *List {
+	c := *l
+	c.Colaborators = slices.Clone(l.Colaborators)
+	c.Tags = slices.Clone(l.Tags)
+	c.Groups = cloneGroups(l.Groups)
+	c.TrashedGroups = cloneGroups(l.TrashedGroups)
+	c.ColaboratorRoles = maps.Clone(l.ColaboratorRoles)
+	return &c
+}
+
+func cloneGroups(groups []*Group) []*Group {
+	if groups == nil {
+		return nil
+	}
+	clones := make([]*Group, 0, len(groups))
+	for _, group := range groups {
+		g := *group
+		g.Items = make([]*Item, 0, len(group.Items))
+		for _, item := range group.Items {
+			i := *item
+			i.Attachments = slices.Clone(item.Attachments)
+			g.Items = append(g.Items, &i)
+		}
+		clones = append(clones, &g)
+	}
+	return clones
 }
 
 type Group struct {
@@ -27,6 +131,7 @@ type Group struct {
 	CreatedAt string
 	Name      string
 	Items     []*Item
+	DeletedAt *time.Time
 }
 
 func (l *List) String() string {
@@ -41,14 +146,38 @@ func (l *List) String() string {
 }
 
 type Item struct {
-	Id          int64  `json:"id"`
-	GroupId     int64  `json:"groupId"`
-	Description string `json:"description"`
-	Quantity    int    `json:"quantity"`
-	Order       int64  `json:"order"`
-	Checked     int8   `json:"checked"`
+	Id          int64   `json:"id"`
+	GroupId     int64   `json:"groupId"`
+	Description string  `json:"description"`
+	Quantity    float64 `json:"quantity"`
+	// Symbol of a unit from pkg/units, empty for plain units
+	Unit    string `json:"unit"`
+	Order   int64  `json:"order"`
+	Checked int8   `json:"checked"`
+	// Price of one unit of the item. Empty currency means the currency of the list.
+	UnitPrice *float64 `json:"unitPrice"`
+	Currency  string   `json:"currency"`
+	// Member of the list that should buy the item, if any
+	AssigneeId *int64 `json:"assigneeId"`
+	// Free text for details the description does not hold, e.g. the brand
+	Note string `json:"note"`
+	// Images of the item, saved apart from the list as they are uploaded
+	Attachments []attachment.Attachment `json:"attachments"`
 }
 
 func (i *Item) String() string {
 	return "Item " + strconv.FormatInt(i.Id, 10) + ": " + i.Description
 }
+
+func (i *Item) QuantityWithUnit() units.Quantity {
+	return units.Quantity{Amount: i.Quantity, Unit: i.Unit}
+}
+
+// Total sums the quantities of the items of the group, e.g. "2 packs, 6 units, 1.5 kg".
+func (g *Group) Total() []units.Quantity {
+	quantities := make([]units.Quantity, 0, len(g.Items))
+	for _, item := range g.Items {
+		quantities = append(quantities, item.QuantityWithUnit())
+	}
+	return units.Aggregate(quantities)
+}
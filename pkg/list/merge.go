@@ -0,0 +1,173 @@
+package list
+
+import (
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/units"
+	"vilmasoftware.com/colablists/pkg/user"
+)
+
+// MergePlan is what merging a source list into a target list does, so it can be
+// previewed before the source goes to the trash.
+type MergePlan struct {
+	Target *List
+	Source *List
+	Groups []*MergedGroup
+	// Members of the source that become colaborators of the target
+	NewColaborators []user.User
+}
+
+// MergedGroup is a group of the merged list with the items it ends up with.
+type MergedGroup struct {
+	Group *Group
+	// The target has no group with the name, so the one of the source is added
+	Added bool
+	Items []*MergedItem
+}
+
+// MergedItem is an item of the merged list and the items it comes from,
+// FromTarget or FromSource being nil when the item is not on that list.
+type MergedItem struct {
+	Item       *Item
+	FromTarget *Item
+	FromSource *Item
+}
+
+// Summed tells whether the item is on both lists and their quantities were added up.
+func (i *MergedItem) Summed() bool {
+	return i.FromTarget != nil && i.FromSource != nil
+}
+
+// PlanMerge combines the groups of the lists with the same name and, in each group,
+// the items with the same description, summing their quantities when the units can be
+// converted into each other. The lists are left untouched.
+func PlanMerge(target, source *List) *MergePlan {
+	plan := &MergePlan{Target: target, Source: source}
+	byName := make(map[string]*MergedGroup)
+	for i, group := range target.CopyGroups(CopyOptions{KeepAssignees: true}) {
+		merged := &MergedGroup{Group: group}
+		for j, item := range group.Items {
+			merged.Items = append(merged.Items, &MergedItem{Item: item, FromTarget: target.Groups[i].Items[j]})
+		}
+		plan.Groups = append(plan.Groups, merged)
+		if _, ok := byName[mergeKey(group.Name)]; !ok {
+			byName[mergeKey(group.Name)] = merged
+		}
+	}
+	for _, group := range source.Groups {
+		merged, ok := byName[mergeKey(group.Name)]
+		if !ok {
+			// Ids of the source would be taken for ones of the target when saving it
+			merged = &MergedGroup{Group: &Group{Name: group.Name, CreatedAt: group.CreatedAt}, Added: true}
+			plan.Groups = append(plan.Groups, merged)
+			byName[mergeKey(group.Name)] = merged
+		}
+		for _, item := range group.Items {
+			merged.add(item)
+		}
+	}
+	for _, group := range plan.Groups {
+		group.Group.Items = make([]*Item, 0, len(group.Items))
+		for _, item := range group.Items {
+			group.Group.Items = append(group.Group.Items, item.Item)
+		}
+	}
+	members := append([]user.User{source.Creator}, source.Colaborators...)
+	for _, member := range members {
+		if !target.IsMember(member.Id) && !plan.isNewColaborator(member.Id) {
+			plan.NewColaborators = append(plan.NewColaborators, member)
+		}
+	}
+	return plan
+}
+
+func (g *MergedGroup) add(item *Item) {
+	key := mergeKey(item.Description)
+	for _, merged := range g.Items {
+		// Items still named like new ones are not the same thing
+		if merged.FromSource != nil || placeholderDescriptions[key] || mergeKey(merged.Item.Description) != key {
+			continue
+		}
+		sum, ok := units.Sum(merged.Item.Unit, merged.Item.QuantityWithUnit(), item.QuantityWithUnit())
+		if !ok {
+			continue
+		}
+		merged.Item.Quantity = sum.Amount
+		merged.FromSource = item
+		// Only what was bought on both lists is bought
+		if item.Checked == 0 {
+			merged.Item.Checked = 0
+		}
+		if merged.Item.UnitPrice == nil && item.UnitPrice != nil {
+			merged.Item.UnitPrice = item.UnitPrice
+			merged.Item.Currency = item.Currency
+		}
+		if merged.Item.AssigneeId == nil {
+			merged.Item.AssigneeId = item.AssigneeId
+		}
+		if note := strings.TrimSpace(item.Note); note != "" && !strings.Contains(merged.Item.Note, note) {
+			merged.Item.Note = strings.TrimSpace(merged.Item.Note + "\n" + note)
+		}
+		return
+	}
+	copied := *item
+	copied.Id = 0
+	copied.GroupId = 0
+	copied.Attachments = nil
+	g.Items = append(g.Items, &MergedItem{Item: &copied, FromSource: item})
+}
+
+func (p *MergePlan) isNewColaborator(userId int64) bool {
+	for _, colaborator := range p.NewColaborators {
+		if colaborator.Id == userId {
+			return true
+		}
+	}
+	return false
+}
+
+// Merged returns a copy of the target with the merged groups and colaborators.
+func (p *MergePlan) Merged() *List {
+	merged := *p.Target
+	merged.Groups = make([]*Group, 0, len(p.Groups))
+	for _, group := range p.Groups {
+		merged.Groups = append(merged.Groups, group.Group)
+	}
+	merged.Colaborators = append(append([]user.User{}, p.Target.Colaborators...), p.NewColaborators...)
+	return &merged
+}
+
+// SummedCount returns how many items of the source are added up to items of the target.
+func (p *MergePlan) SummedCount() int {
+	count := 0
+	for _, group := range p.Groups {
+		for _, item := range group.Items {
+			if item.Summed() {
+				count++
+			}
+		}
+	}
+	return count
+}
+
+func mergeKey(name string) string {
+	return strings.ToLower(strings.TrimSpace(name))
+}
+
+// AddItem puts the item in the group, adding its quantity to an unchecked item with the same
+// description when the units can be converted into each other. It returns the item holding it.
+func (g *Group) AddItem(item *Item) *Item {
+	key := mergeKey(item.Description)
+	for _, existing := range g.Items {
+		if existing.Checked != 0 || mergeKey(existing.Description) != key {
+			continue
+		}
+		if sum, ok := units.Sum(existing.Unit, existing.QuantityWithUnit(), item.QuantityWithUnit()); ok {
+			existing.Quantity = sum.Amount
+			return existing
+		}
+	}
+	item.GroupId = g.GroupId
+	g.Items = append(g.Items, item)
+	return item
+}
@@ -0,0 +1,113 @@
+package list
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"unicode/utf8"
+)
+
+// ListsQuery filters, sorts and paginates the lists of a user. The zero value returns all of them.
+type ListsQuery struct {
+	Tag         string
+	CommunityId *int64
+	CreatorId   *int64
+	Status      string
+	Sort        string
+	// Cursor of the page, as returned by the previous one
+	Cursor string
+	// Zero returns every list
+	Limit int
+}
+
+const (
+	// Lists with unchecked items, or none at all
+	StatusOpen = "open"
+	// Lists with every item checked
+	StatusDone = "done"
+)
+
+const (
+	SortUpdated = "updated"
+	SortCreated = "created"
+	SortTitle   = "title"
+	SortDue     = "due"
+)
+
+// Sorts are the ways lists can be sorted, the default first.
+var Sorts = []string{SortUpdated, SortCreated, SortTitle, SortDue}
+
+type ListsPage struct {
+	Lists []List
+	// Cursor of the next page, empty on the last one
+	NextCursor string
+}
+
+// sortSpec orders the lists by an expression, the listId breaking ties so that
+// cursors point to a single position.
+type sortSpec struct {
+	expression string
+	descending bool
+}
+
+var sortSpecs = map[string]sortSpec{
+	SortUpdated: {expression: "CAST(l.updatedAt AS TEXT)", descending: true},
+	SortCreated: {expression: "l.listId", descending: true},
+	SortTitle:   {expression: "lower(l.title)"},
+	// Lists without due date last
+	SortDue: {expression: "COALESCE(CAST(l.dueAt AS TEXT), '9999')"},
+}
+
+var (
+	ErrInvalidSort   = errors.New("sort should be one of " + strings.Join(Sorts, ", "))
+	ErrInvalidStatus = errors.New("status should be open or done")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// cursor is the position after the last list of a page.
+type cursor struct {
+	Sort  string `json:"s"`
+	Value any    `json:"v"`
+	Id    int64  `json:"id"`
+}
+
+func encodeCursor(c cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// decodeCursor reads a cursor, which must come from a page with the same sort.
+func decodeCursor(value string, sort string) (cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return cursor{}, ErrInvalidCursor
+	}
+	c := cursor{}
+	if err := json.Unmarshal(b, &c); err != nil || c.Sort != sort {
+		return cursor{}, ErrInvalidCursor
+	}
+	return c, nil
+}
+
+// Tags are up to this many characters
+const maxTagLength = 30
+
+// NormalizeTags reads comma separated tags, lowercased, without repetitions.
+func NormalizeTags(text string) []string {
+	tags := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, tag := range strings.Split(text, ",") {
+		tag = strings.ToLower(strings.Join(strings.Fields(tag), " "))
+		for utf8.RuneCountInString(tag) > maxTagLength {
+			_, size := utf8.DecodeLastRuneInString(tag)
+			tag = tag[:len(tag)-size]
+		}
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		tags = append(tags, tag)
+	}
+	return tags
+}
@@ -1,9 +1,50 @@
 package list
 
+import "time"
+
 type ListsRepository interface {
-	GetAll(userId int64) ([]List, error)
+	// GetAll returns the lists the user can see, leaving out the archived ones, templates and the trash.
+	GetAll(userId int64, query ListsQuery) (ListsPage, error)
+	// FindTags returns the tags of the lists the user can see.
+	FindTags(userId int64) ([]string, error)
 	Get(id int64) (List, error)
 	Create(list *ListCreationParams) (List, error)
 	Update(list *List) (*List, error)
+	// Delete moves the list of the user to the trash.
 	Delete(listId int64, userId int64) error
+	Restore(listId int64, userId int64) error
+	// Purge deletes a list of the user in the trash for good, returning the storage keys
+	// of its attachment files, which the caller must delete.
+	Purge(listId int64, userId int64) ([]string, error)
+	RestoreGroup(listId int64, groupId int64) error
+	PurgeGroup(listId int64, groupId int64) ([]string, error)
+	FindTrash(userId int64) (Trash, error)
+	// Suggest returns the items the user or their communities put on lists before that
+	// match the text being typed, the most used and recent first.
+	Suggest(userId int64, text string, limit int) ([]Suggestion, error)
+	// Search returns the lists the user can see matching the text, best matches first.
+	Search(userId int64, text string, limit int) ([]SearchResult, error)
+	// FindItem returns where a saved item is, or sql.ErrNoRows.
+	FindItem(itemId int64) (listId int64, groupId int64, err error)
+	// FindAssignedItems returns the saved items assigned to the user in the lists they can see,
+	// leaving out the archived ones, templates and the trash.
+	FindAssignedItems(userId int64) ([]AssignedItem, error)
+	// CheckItem saves whether an item of the list is checked, leaving the rest of the list
+	// as it was saved. Items not saved yet are left alone.
+	CheckItem(listId int64, itemId int64, checked int8) error
+	// PurgeTrash deletes for good the lists and groups trashed before the given time,
+	// returning how many and the storage keys of their attachment files.
+	PurgeTrash(before time.Time) (int, []string, error)
+	// FindOccurrencesDue returns the ids of the recurring lists whose next occurrence is not after t.
+	FindOccurrencesDue(t time.Time) ([]int64, error)
+	Archive(listId int64, archivedAt time.Time) error
+	// FindArchivedOccurrences returns the previous occurrences of a recurring list, most recent first.
+	FindArchivedOccurrences(seriesId int64) ([]List, error)
+	// Duplicate deep copies a list into a new one.
+	Duplicate(params *DuplicateParams) (List, error)
+	// Merge saves the target of the plan with the groups, items and colaborators of the source,
+	// then moves the source, which must be of the user, to the trash.
+	Merge(plan *MergePlan, userId int64) (List, error)
+	// FindTemplates returns the templates of the user and of the communities they belong to.
+	FindTemplates(userId int64) ([]List, error)
 }
@@ -0,0 +1,110 @@
+package list
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Role is what a member can do with a list.
+type Role string
+
+const (
+	// The owner manages the colaborators and can delete the list. Each list has one, its creator.
+	RoleOwner Role = "owner"
+	// Editors change the list and its items
+	RoleEditor Role = "editor"
+	// Commenters see the list and write notes on its items
+	RoleCommenter Role = "commenter"
+	// Viewers only see the list
+	RoleViewer Role = "viewer"
+)
+
+var ErrNotColaborator = errors.New("ownership can only be transferred to a colaborator of the list")
+
+func ParseRole(s string) (Role, error) {
+	switch role := Role(s); role {
+	case RoleOwner, RoleEditor, RoleCommenter, RoleViewer:
+		return role, nil
+	case "":
+		return RoleEditor, nil
+	}
+	return "", fmt.Errorf("unknown role %q", s)
+}
+
+func (r Role) CanEdit() bool {
+	return r == RoleOwner || r == RoleEditor
+}
+
+func (r Role) CanComment() bool {
+	return r.CanEdit() || r == RoleCommenter
+}
+
+// CanManage tells whether the role changes the colaborators of the list and their roles.
+func (r Role) CanManage() bool {
+	return r == RoleOwner
+}
+
+// RoleOf returns the role of the user in the list, empty when they can't see it. Colaborators
+// without a role, such as the ones merged from another list, and the members of the community
+// of the list who are not colaborators are editors.
+func (l *List) RoleOf(userId int64) Role {
+	if l.Creator.Id == userId {
+		return RoleOwner
+	}
+	if l.isColaborator(userId) {
+		// Owners of lists the colaborators were copied from are not owners here
+		if role, ok := l.ColaboratorRoles[userId]; ok && role != RoleOwner {
+			return role
+		}
+		return RoleEditor
+	}
+	if l.Community != nil && l.Community.IsMember(userId) {
+		return RoleEditor
+	}
+	return ""
+}
+
+// SetRole gives a role to a colaborator, who can't be made owner this way, see TransferOwnership.
+func (l *List) SetRole(userId int64, role Role) {
+	if role == RoleOwner {
+		role = RoleEditor
+	}
+	if l.ColaboratorRoles == nil {
+		l.ColaboratorRoles = make(map[int64]Role)
+	}
+	l.ColaboratorRoles[userId] = role
+}
+
+// TransferOwnership makes a colaborator the owner of the list, the previous owner staying as an editor.
+func (l *List) TransferOwnership(userId int64) error {
+	if userId == l.Creator.Id {
+		return nil
+	}
+	index := -1
+	for i, colaborator := range l.Colaborators {
+		if colaborator.Id == userId {
+			index = i
+		}
+	}
+	if index == -1 {
+		return ErrNotColaborator
+	}
+	previous := l.Creator
+	l.Creator = l.Colaborators[index]
+	if !l.isColaborator(previous.Id) {
+		l.Colaborators = append(l.Colaborators, previous)
+	}
+	l.SetRole(previous.Id, RoleEditor)
+	delete(l.ColaboratorRoles, userId)
+	return nil
+}
+
+// isColaborator tells whether the user is among the colaborators, which usually include the owner.
+func (l *List) isColaborator(userId int64) bool {
+	for _, colaborator := range l.Colaborators {
+		if colaborator.Id == userId {
+			return true
+		}
+	}
+	return false
+}
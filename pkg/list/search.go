@@ -0,0 +1,33 @@
+package list
+
+import (
+	"html"
+	"strings"
+)
+
+type SearchResult struct {
+	List List
+	// Excerpt of the list around the matches, as HTML with them in <mark>
+	Snippet string
+}
+
+// MatchQuery turns the text typed by the user into an FTS5 query matching lists
+// with words starting with every one typed, e.g. bbq sup -> "bbq"* "sup"*.
+// Quoting keeps the FTS5 syntax out of what users type.
+func MatchQuery(text string) string {
+	terms := make([]string, 0)
+	for _, word := range strings.Fields(text) {
+		word = strings.ReplaceAll(word, `"`, "")
+		if word == "" {
+			continue
+		}
+		terms = append(terms, `"`+word+`"*`)
+	}
+	return strings.Join(terms, " ")
+}
+
+// HighlightSnippet escapes the snippet, turning the match markers into <mark> tags.
+func HighlightSnippet(snippet string) string {
+	escaped := html.EscapeString(snippet)
+	return strings.NewReplacer("\x02", "<mark>", "\x03", "</mark>").Replace(escaped)
+}
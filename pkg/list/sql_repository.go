@@ -3,9 +3,12 @@ package list
 import (
 	"database/sql"
 	"errors"
+	"fmt"
 	"log"
+	"strings"
 	"time"
 
+	"vilmasoftware.com/colablists/pkg/attachment"
 	"vilmasoftware.com/colablists/pkg/community"
 	infra "vilmasoftware.com/colablists/pkg/infra"
 	"vilmasoftware.com/colablists/pkg/user"
@@ -42,9 +45,9 @@ func (s *SqlListRepository) Create(list *ListCreationParams) (List, error) {
 	}
 
 	_, err = tx.Exec(`
-    INSERT INTO list_colaborators (listId, luserId)
-    VALUES (?, ?)
-  `, listId, list.CreatorId)
+    INSERT INTO list_colaborators (listId, luserId, role)
+    VALUES (?, ?, ?)
+  `, listId, list.CreatorId, RoleOwner)
 	if err != nil {
 		return List{}, err
 	}
@@ -62,6 +65,9 @@ func (s *SqlListRepository) Create(list *ListCreationParams) (List, error) {
 	if err != nil {
 		return List{}, err
 	}
+	if err = indexList(tx, listId); err != nil {
+		return List{}, err
+	}
 
 	if err = tx.Commit(); err != nil {
 		return List{}, err
@@ -77,22 +83,321 @@ func (s *SqlListRepository) Delete(listId, userId int64) error {
 	if err != nil {
 		return err
 	}
-	if _, err := db.Exec(`DELETE FROM list where listId = ? and creatorLuserId = ?`, listId, userId); err != nil {
+	defer db.Close()
+	result, err := db.Exec(`UPDATE list SET deletedAt = ? WHERE listId = ? AND creatorLuserId = ? AND deletedAt IS NULL`, time.Now().UTC(), listId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Restore implements ListsRepository.
+func (s *SqlListRepository) Restore(listId, userId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	result, err := db.Exec(`UPDATE list SET deletedAt = NULL WHERE listId = ? AND creatorLuserId = ? AND deletedAt IS NOT NULL`, listId, userId)
+	if err != nil {
+		return err
+	}
+	return expectAffected(result)
+}
+
+// Purge implements ListsRepository.
+func (s *SqlListRepository) Purge(listId, userId int64) ([]string, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	var found int64
+	err = tx.QueryRow(`SELECT listId FROM list WHERE listId = ? AND creatorLuserId = ? AND deletedAt IS NOT NULL`, listId, userId).Scan(&found)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotInTrash
+	} else if err != nil {
+		return nil, err
+	}
+	files, err := purgeList(tx, listId)
+	if err != nil {
+		return nil, err
+	}
+	return files, tx.Commit()
+}
+
+// RestoreGroup implements ListsRepository.
+func (s *SqlListRepository) RestoreGroup(listId, groupId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(`UPDATE list_groups SET deletedAt = NULL WHERE groupId = ? AND listId = ? AND deletedAt IS NOT NULL`, groupId, listId)
+	if err != nil {
+		return err
+	}
+	if err = expectAffected(result); err != nil {
+		return err
+	}
+	if err = indexList(tx, listId); err != nil {
 		return err
 	}
+	return tx.Commit()
+}
+
+// PurgeGroup implements ListsRepository.
+func (s *SqlListRepository) PurgeGroup(listId, groupId int64) ([]string, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(`DELETE FROM list_groups WHERE groupId = ? AND listId = ? AND deletedAt IS NOT NULL`, groupId, listId)
+	if err != nil {
+		return nil, err
+	}
+	if err = expectAffected(result); err != nil {
+		return nil, err
+	}
+	files, err := purgeGroupItems(tx, groupId)
+	if err != nil {
+		return nil, err
+	}
+	return files, tx.Commit()
+}
+
+// FindTrash implements ListsRepository.
+func (s *SqlListRepository) FindTrash(userId int64) (Trash, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Trash{}, err
+	}
+	defer db.Close()
+	trash := Trash{Lists: make([]List, 0), Groups: make([]TrashedGroup, 0)}
+	rows, err := db.Query(`
+  SELECT `+listColumns+`
+  FROM list l
+  WHERE l.creatorLuserId = ? AND l.deletedAt IS NOT NULL
+  ORDER BY l.deletedAt DESC
+  `, userId)
+	if err != nil {
+		return Trash{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		l, err := scanList(rows)
+		if err != nil {
+			return Trash{}, err
+		}
+		trash.Lists = append(trash.Lists, l)
+	}
+	if err := rows.Err(); err != nil {
+		return Trash{}, err
+	}
+	groupRows, err := db.Query(`
+  SELECT g.groupId, g.listId, g.createdAt, g.name, g.deletedAt, l.title
+  FROM list_groups g
+  INNER JOIN list l ON l.listId = g.listId
+  WHERE g.deletedAt IS NOT NULL AND l.deletedAt IS NULL
+  AND (l.creatorLuserId = ? OR l.listId IN (SELECT listId FROM list_colaborators WHERE luserId = ?))
+  ORDER BY g.deletedAt DESC
+  `, userId, userId)
+	if err != nil {
+		return Trash{}, err
+	}
+	defer groupRows.Close()
+	for groupRows.Next() {
+		g := &Group{}
+		trashed := TrashedGroup{Group: g}
+		if err := groupRows.Scan(&g.GroupId, &g.ListId, &g.CreatedAt, &g.Name, &g.DeletedAt, &trashed.ListTitle); err != nil {
+			return Trash{}, err
+		}
+		trashed.ListId = g.ListId
+		trash.Groups = append(trash.Groups, trashed)
+	}
+	if err := groupRows.Err(); err != nil {
+		return Trash{}, err
+	}
+	for _, trashed := range trash.Groups {
+		if trashed.Group.Items, err = getItems(db, trashed.Group.GroupId); err != nil {
+			return Trash{}, err
+		}
+	}
+	return trash, nil
+}
+
+// PurgeTrash implements ListsRepository.
+func (s *SqlListRepository) PurgeTrash(before time.Time) (int, []string, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer tx.Rollback()
+	// Deletion times are stored in UTC, so they compare as text
+	listIds, err := queryIds(tx, `SELECT listId FROM list WHERE deletedAt < ?`, before.UTC())
+	if err != nil {
+		return 0, nil, err
+	}
+	var files []string
+	for listId := range listIds {
+		listFiles, err := purgeList(tx, listId)
+		if err != nil {
+			return 0, nil, err
+		}
+		files = append(files, listFiles...)
+	}
+	groupIds, err := queryIds(tx, `SELECT groupId FROM list_groups WHERE deletedAt < ?`, before.UTC())
+	if err != nil {
+		return 0, nil, err
+	}
+	for groupId := range groupIds {
+		groupFiles, err := purgeGroupItems(tx, groupId)
+		if err != nil {
+			return 0, nil, err
+		}
+		files = append(files, groupFiles...)
+		if _, err = tx.Exec(`DELETE FROM list_groups WHERE groupId = ?`, groupId); err != nil {
+			return 0, nil, err
+		}
+	}
+	return len(listIds) + len(groupIds), files, tx.Commit()
+}
+
+// purgeGroupItems deletes the items of the group with their attachments,
+// returning the storage keys of the files left to delete.
+func purgeGroupItems(tx *sql.Tx, groupId int64) ([]string, error) {
+	files, err := purgeAttachments(tx, `SELECT itemId FROM list_group_items WHERE groupId = ?`, groupId)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(`DELETE FROM list_group_items WHERE groupId = ?`, groupId); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// purgeAttachments deletes the attachments of the items selected by the query,
+// returning the storage keys of their files and thumbnails.
+func purgeAttachments(tx *sql.Tx, itemsQuery string, id int64) ([]string, error) {
+	rows, err := tx.Query(`SELECT storageKey, thumbnailKey FROM item_attachment WHERE itemId IN (`+itemsQuery+`)`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var files []string
+	for rows.Next() {
+		var storageKey, thumbnailKey string
+		if err = rows.Scan(&storageKey, &thumbnailKey); err != nil {
+			return nil, err
+		}
+		files = append(files, storageKey, thumbnailKey)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	if _, err = tx.Exec(`DELETE FROM item_attachment WHERE itemId IN (`+itemsQuery+`)`, id); err != nil {
+		return nil, err
+	}
+	return files, nil
+}
+
+// purgeList deletes the list for good, with everything that only makes sense with it,
+// returning the storage keys of the attachment files left to delete.
+// Shopping trips are kept, as they record what happened.
+func purgeList(tx *sql.Tx, listId int64) ([]string, error) {
+	files, err := purgeAttachments(tx, `SELECT i.itemId FROM list_group_items i INNER JOIN list_groups g ON g.groupId = i.groupId WHERE g.listId = ?`, listId)
+	if err != nil {
+		return nil, err
+	}
+	statements := []string{
+		`DELETE FROM list_group_items WHERE groupId IN (SELECT groupId FROM list_groups WHERE listId = ?)`,
+		`DELETE FROM list_groups WHERE listId = ?`,
+		`DELETE FROM list_colaborators WHERE listId = ?`,
+		`DELETE FROM list_reminder_sent WHERE listId = ?`,
+		`DELETE FROM list_reminder_preference WHERE listId = ?`,
+		`DELETE FROM list_tag WHERE listId = ?`,
+		`UPDATE pantry SET shoppingListId = NULL WHERE shoppingListId = ?`,
+		`DELETE FROM delivery_order_line WHERE orderId IN (SELECT orderId FROM delivery_order WHERE listId = ?)`,
+		`DELETE FROM delivery_order WHERE listId = ?`,
+		`DELETE FROM list_share WHERE listId = ?`,
+		`DELETE FROM list_fts WHERE rowid = ?`,
+		`DELETE FROM list WHERE listId = ?`,
+	}
+	for _, statement := range statements {
+		if _, err := tx.Exec(statement, listId); err != nil {
+			return nil, err
+		}
+	}
+	return files, nil
+}
+
+var ErrNotInTrash = errors.New("not found in the trash")
+
+func expectAffected(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotInTrash
+	}
 	return nil
 }
 
+// queryIds returns the set of ids selected by the query.
+func queryIds(tx infra.Queryable, query string, args ...any) (map[int64]bool, error) {
+	rows, err := tx.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make(map[int64]bool)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+	return ids, rows.Err()
+}
+
 type Scanner interface {
 	Scan(dest ...interface{}) error
 }
 
+// Columns read by scanList, for a query on list aliased as l.
+const listColumns = `l.listId, l.title, l.description, l.creatorLuserId, l.updatedAt, l.communityId, l.budget, l.currency, l.dueAt,
+  l.recurrence, l.recurrenceMode, l.nextOccurrenceAt, l.seriesId, l.archivedAt, l.isTemplate, l.deletedAt`
+
 func scanList(row Scanner) (List, error) {
 	l := &List{
 		Creator: user.User{},
 	}
 	var communityId *int64
-	err := row.Scan(&l.Id, &l.Title, &l.Description, &l.Creator.Id, &l.UpdatedAt, &communityId)
+	err := row.Scan(&l.Id, &l.Title, &l.Description, &l.Creator.Id, &l.UpdatedAt, &communityId, &l.Budget, &l.Currency, &l.DueAt,
+		&l.Recurrence, &l.RecurrenceMode, &l.NextOccurrenceAt, &l.SeriesId, &l.ArchivedAt, &l.IsTemplate, &l.DeletedAt)
 	if err != nil {
 		return List{}, err
 	}
@@ -112,6 +417,32 @@ func GetCommunity(tx infra.Queryable, comm *community.Community) error {
 	return nil
 }
 
+func getCreator(tx infra.Queryable, creator *user.User) error {
+	err := user.ScanUser(tx.QueryRow(`SELECT * FROM luser WHERE luserId = ?`, creator.Id), creator)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil
+	}
+	return err
+}
+
+func getRoles(tx infra.Queryable, listId int64) (map[int64]Role, error) {
+	rows, err := tx.Query(`SELECT luserId, role FROM list_colaborators WHERE listId = ?`, listId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	roles := make(map[int64]Role)
+	for rows.Next() {
+		var userId int64
+		var role Role
+		if err := rows.Scan(&userId, &role); err != nil {
+			return nil, err
+		}
+		roles[userId] = role
+	}
+	return roles, rows.Err()
+}
+
 // Get implements ListsRepository.
 func (s *SqlListRepository) Get(id int64) (List, error) {
 	sql, err := infra.CreateConnection()
@@ -124,9 +455,9 @@ func (s *SqlListRepository) Get(id int64) (List, error) {
 	}
 	defer tx.Rollback()
 	stmt, err := tx.Prepare(`
-    SELECT *
-    FROM list
-    Where listId = ?
+    SELECT ` + listColumns + `
+    FROM list l
+    Where l.listId = ?
     `)
 	if err != nil {
 		log.Fatal(err)
@@ -142,6 +473,13 @@ func (s *SqlListRepository) Get(id int64) (List, error) {
 		if err = GetCommunity(tx, resultlis.Community); err != nil {
 			return List{}, err
 		}
+		// Members of the community edit its lists, see RoleOf
+		if _, err = (&community.HouseRepository{}).GetMembers(tx, resultlis.Community); err != nil {
+			return List{}, err
+		}
+	}
+	if err = getCreator(tx, &resultlis.Creator); err != nil {
+		return List{}, err
 	}
 
 	stmt, err = tx.Prepare(`
@@ -167,11 +505,20 @@ func (s *SqlListRepository) Get(id int64) (List, error) {
 		colaborators = append(colaborators, u)
 	}
 	resultlis.Colaborators = colaborators
+	if resultlis.ColaboratorRoles, err = getRoles(tx, id); err != nil {
+		return List{}, err
+	}
+	withTags := []List{resultlis}
+	if err = getTags(tx, withTags); err != nil {
+		return List{}, err
+	}
+	resultlis.Tags = withTags[0].Tags
 
 	stmt, err = tx.Prepare(`
-    SELECT *
+    SELECT groupId, listId, createdAt, name, deletedAt
     FROM list_groups
     WHERE listId = ?
+    ORDER BY groupId
     `)
 	if err != nil {
 		return List{}, err
@@ -182,93 +529,442 @@ func (s *SqlListRepository) Get(id int64) (List, error) {
 	}
 	defer rs2.Close()
 	groups := make([]*Group, 0)
+	trashed := make([]*Group, 0)
 	for rs2.Next() {
-		g := &Group{Items: make([]*Item, 0)}
-		err := rs2.Scan(&g.GroupId, &g.ListId, &g.CreatedAt, &g.Name)
+		g := &Group{}
+		err := rs2.Scan(&g.GroupId, &g.ListId, &g.CreatedAt, &g.Name, &g.DeletedAt)
 		if err != nil {
 			return List{}, err
 		}
-		stmt, err = tx.Prepare(`
-        SELECT *
-        FROM list_group_items
-        WHERE groupId = ?
-        `)
+		g.Items, err = getItems(tx, g.GroupId)
 		if err != nil {
 			return List{}, err
 		}
-		rsg, err := stmt.Query(g.GroupId)
-		if err != nil {
-			return List{}, err
-		}
-		defer rsg.Close()
-		for rsg.Next() {
-			i := Item{}
-			err := rsg.Scan(&i.Id, &i.GroupId, &i.Description, &i.Quantity, &i.Order, &i.Checked)
-			if err != nil {
-				return List{}, err
-			}
-			g.Items = append(g.Items, &i)
+		if g.DeletedAt != nil {
+			trashed = append(trashed, g)
+		} else {
+			groups = append(groups, g)
 		}
-		groups = append(groups, g)
 	}
 	err = tx.Commit()
 	if err != nil {
 		return List{}, err
 	}
 	resultlis.Groups = groups
+	resultlis.TrashedGroups = trashed
 	return resultlis, nil
 }
 
-// GetAll implements ListsRepository.
-func (s *SqlListRepository) GetAll(userId int64) ([]List, error) {
+func getItems(tx infra.Queryable, groupId int64) ([]*Item, error) {
+	rows, err := tx.Query(`
+  SELECT itemId, groupId, description, quantity, unit, order_, checked, unitPrice, currency, assigneeLuserId, note
+  FROM list_group_items
+  WHERE groupId = ?
+  ORDER BY order_, itemId
+  `, groupId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]*Item, 0)
+	itemsById := make(map[int64]*Item)
+	for rows.Next() {
+		i := &Item{Attachments: make([]attachment.Attachment, 0)}
+		err := rows.Scan(&i.Id, &i.GroupId, &i.Description, &i.Quantity, &i.Unit, &i.Order, &i.Checked, &i.UnitPrice, &i.Currency, &i.AssigneeId, &i.Note)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+		itemsById[i.Id] = i
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	attachments, err := tx.Query(`
+  SELECT `+attachment.Columns+`
+  FROM item_attachment a
+  INNER JOIN list_group_items i ON i.itemId = a.itemId
+  WHERE i.groupId = ?
+  ORDER BY a.attachmentId
+  `, groupId)
+	if err != nil {
+		return nil, err
+	}
+	defer attachments.Close()
+	for attachments.Next() {
+		a, err := attachment.Scan(attachments)
+		if err != nil {
+			return nil, err
+		}
+		if item, ok := itemsById[a.ItemId]; ok {
+			item.Attachments = append(item.Attachments, a)
+		}
+	}
+	return items, attachments.Err()
+}
+
+// Suggest implements ListsRepository.
+func (s *SqlListRepository) Suggest(userId int64, text string, limit int) ([]Suggestion, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return make([]Suggestion, 0), nil
+	}
 	db, err := infra.CreateConnection()
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 	defer db.Close()
-	rs, err := db.Query(`
-  SELECT l.*
+	escaped := likeEscaper.Replace(text)
+	// Items starting with the text, or with a word starting with it
+	rows, err := db.Query(`
+  SELECT i.description, i.quantity, i.unit, g.name, l.updatedAt
+  FROM list_group_items i
+  INNER JOIN list_groups g ON g.groupId = i.groupId
+  INNER JOIN list l ON l.listId = g.listId
+  WHERE `+visibleToUser+`
+  AND l.deletedAt IS NULL AND g.deletedAt IS NULL
+  AND (i.description LIKE ? ESCAPE '\' OR i.description LIKE ? ESCAPE '\')
+  ORDER BY l.updatedAt DESC
+  LIMIT 1000
+  `, userId, userId, userId, userId, escaped+"%", "% "+escaped+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	uses := make([]SuggestionUse, 0)
+	for rows.Next() {
+		use := SuggestionUse{}
+		if err := rows.Scan(&use.Description, &use.Quantity, &use.Unit, &use.GroupName, &use.UsedAt); err != nil {
+			return nil, err
+		}
+		uses = append(uses, use)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return RankSuggestions(uses, time.Now(), limit), nil
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// indexList updates the full-text index of the list with its title, description,
+// and the names of its groups and items, leaving out the trashed ones.
+func indexList(tx *sql.Tx, listId int64) error {
+	if _, err := tx.Exec(`DELETE FROM list_fts WHERE rowid = ?`, listId); err != nil {
+		return err
+	}
+	_, err := tx.Exec(`
+  INSERT INTO list_fts (rowid, title, description, groups, items)
+  SELECT l.listId, l.title, l.description,
+    (SELECT group_concat(g.name, ' | ') FROM list_groups g WHERE g.listId = l.listId AND g.deletedAt IS NULL),
+    (SELECT group_concat(i.description, ' | ') FROM list_group_items i
+      INNER JOIN list_groups g ON g.groupId = i.groupId
+      WHERE g.listId = l.listId AND g.deletedAt IS NULL)
   FROM list l
-  WHERE l.creatorLuserId = ?
+  WHERE l.listId = ?
+  `, listId)
+	return err
+}
+
+// Search implements ListsRepository.
+func (s *SqlListRepository) Search(userId int64, text string, limit int) ([]SearchResult, error) {
+	query := MatchQuery(text)
+	if query == "" {
+		return make([]SearchResult, 0), nil
+	}
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// The snippet marks the matches with control characters, replaced after escaping it
+	rows, err := db.Query(`
+  SELECT `+listColumns+`, snippet(list_fts, -1, char(2), char(3), '…', 12)
+  FROM list_fts f
+  INNER JOIN list l ON l.listId = f.rowid
+  WHERE list_fts MATCH ?
+  AND `+visibleToUser+`
+  AND l.deletedAt IS NULL
+  ORDER BY bm25(list_fts, 10.0, 5.0, 2.0, 1.0)
+  LIMIT ?
+  `, query, userId, userId, userId, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	results := make([]SearchResult, 0)
+	for rows.Next() {
+		var snippet string
+		l, err := scanList(extraScanner{rows, []any{&snippet}})
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, SearchResult{List: l, Snippet: HighlightSnippet(snippet)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for _, result := range results {
+		if result.List.Community != nil {
+			if err := GetCommunity(db, result.List.Community); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return results, nil
+}
+
+// extraScanner reads columns selected after the ones of the list.
+type extraScanner struct {
+	row   Scanner
+	extra []any
+}
+
+func (s extraScanner) Scan(dest ...interface{}) error {
+	return s.row.Scan(append(dest, s.extra...)...)
+}
+
+// FindItem implements ListsRepository.
+func (s *SqlListRepository) FindItem(itemId int64) (listId int64, groupId int64, err error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer db.Close()
+	err = db.QueryRow(`
+  SELECT g.listId, g.groupId
+  FROM list_group_items i
+  INNER JOIN list_groups g ON g.groupId = i.groupId
+  WHERE i.itemId = ?
+  `, itemId).Scan(&listId, &groupId)
+	return listId, groupId, err
+}
+
+// CheckItem implements ListsRepository.
+func (s *SqlListRepository) CheckItem(listId int64, itemId int64, checked int8) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+  UPDATE list_group_items SET checked = ?
+  WHERE itemId = ? AND groupId IN (SELECT groupId FROM list_groups WHERE listId = ?)
+  `, checked, itemId, listId)
+	return err
+}
+
+// FindAssignedItems implements ListsRepository.
+func (s *SqlListRepository) FindAssignedItems(userId int64) ([]AssignedItem, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+  SELECT l.listId, l.title, g.name,
+    i.itemId, i.groupId, i.description, i.quantity, i.unit, i.order_, i.checked, i.unitPrice, i.currency, i.assigneeLuserId, i.note
+  FROM list_group_items i
+  INNER JOIN list_groups g ON g.groupId = i.groupId
+  INNER JOIN list l ON l.listId = g.listId
+  WHERE i.assigneeLuserId = ?
+  AND `+visibleToUser+`
+  AND l.archivedAt IS NULL AND l.deletedAt IS NULL AND l.isTemplate = 0
+  AND g.deletedAt IS NULL
+  ORDER BY l.listId, g.groupId, i.order_, i.itemId
+  `, userId, userId, userId, userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	items := make([]AssignedItem, 0)
+	for rows.Next() {
+		a := AssignedItem{Item: &Item{Attachments: make([]attachment.Attachment, 0)}}
+		i := a.Item
+		err := rows.Scan(&a.ListId, &a.ListTitle, &a.GroupName,
+			&i.Id, &i.GroupId, &i.Description, &i.Quantity, &i.Unit, &i.Order, &i.Checked, &i.UnitPrice, &i.Currency, &i.AssigneeId, &i.Note)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, a)
+	}
+	return items, rows.Err()
+}
+
+// Lists the user can see, for a query on list aliased as l, taking the user id four times.
+const visibleToUser = `(l.creatorLuserId = ?
   OR l.listId IN (SELECT listId FROM list_colaborators WHERE luserId = ?)
-  OR l.listId IN (SELECT listId FROM community_members WHERE memberId = ?)
-  ORDER BY l.updatedAt DESC
-  `, userId, userId, userId)
-	if err == sql.ErrNoRows {
-		return make([]List, 0), nil
-	} else if err != nil {
-		panic(err)
+  OR l.communityId IN (SELECT c.communityId FROM community c WHERE c.createdByLuserId = ?)
+  OR l.communityId IN (SELECT m.communityId FROM community_members m WHERE m.memberId = ?))`
+
+// Lists with items, all of them checked
+const listDone = `(EXISTS (SELECT 1 FROM list_group_items i INNER JOIN list_groups g ON g.groupId = i.groupId
+    WHERE g.listId = l.listId AND g.deletedAt IS NULL)
+  AND NOT EXISTS (SELECT 1 FROM list_group_items i INNER JOIN list_groups g ON g.groupId = i.groupId
+    WHERE g.listId = l.listId AND g.deletedAt IS NULL AND i.checked = 0))`
+
+// GetAll implements ListsRepository.
+func (s *SqlListRepository) GetAll(userId int64, query ListsQuery) (ListsPage, error) {
+	sort := query.Sort
+	if sort == "" {
+		sort = SortUpdated
 	}
-	defer rs.Close()
-	ls := make([]List, 0)
-	for rs.Next() {
-		l, err := scanList(rs)
+	spec, ok := sortSpecs[sort]
+	if !ok {
+		return ListsPage{}, ErrInvalidSort
+	}
+	where := []string{visibleToUser, "l.archivedAt IS NULL", "l.deletedAt IS NULL", "l.isTemplate = 0"}
+	args := []any{userId, userId, userId, userId}
+	if query.Tag != "" {
+		where = append(where, "l.listId IN (SELECT listId FROM list_tag WHERE tag = ?)")
+		args = append(args, strings.ToLower(query.Tag))
+	}
+	if query.CommunityId != nil {
+		where = append(where, "l.communityId = ?")
+		args = append(args, *query.CommunityId)
+	}
+	if query.CreatorId != nil {
+		where = append(where, "l.creatorLuserId = ?")
+		args = append(args, *query.CreatorId)
+	}
+	switch query.Status {
+	case "":
+	case StatusDone:
+		where = append(where, listDone)
+	case StatusOpen:
+		where = append(where, "NOT "+listDone)
+	default:
+		return ListsPage{}, ErrInvalidStatus
+	}
+	direction, comparison := "ASC", ">"
+	if spec.descending {
+		direction, comparison = "DESC", "<"
+	}
+	if query.Cursor != "" {
+		c, err := decodeCursor(query.Cursor, sort)
 		if err != nil {
-			panic(err)
+			return ListsPage{}, err
 		}
-		ls = append(ls, l)
+		where = append(where, fmt.Sprintf("(%[1]s %[2]s ? OR (%[1]s = ? AND l.listId %[2]s ?))", spec.expression, comparison))
+		args = append(args, c.Value, c.Value, c.Id)
+	}
+	sqlQuery := `SELECT ` + listColumns + `, ` + spec.expression + `
+  FROM list l
+  WHERE ` + strings.Join(where, "\n  AND ") + `
+  ORDER BY ` + spec.expression + ` ` + direction + `, l.listId ` + direction
+	if query.Limit > 0 {
+		// One more tells whether there is a next page
+		sqlQuery += "\n  LIMIT ?"
+		args = append(args, query.Limit+1)
+	}
+
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return ListsPage{}, err
+	}
+	defer db.Close()
+	rows, err := db.Query(sqlQuery, args...)
+	if err != nil {
+		return ListsPage{}, err
+	}
+	defer rows.Close()
+	page := ListsPage{Lists: make([]List, 0)}
+	var lastValue any
+	for rows.Next() {
+		var value any
+		l, err := scanList(extraScanner{rows, []any{&value}})
+		if err != nil {
+			return ListsPage{}, err
+		}
+		if query.Limit > 0 && len(page.Lists) == query.Limit {
+			last := page.Lists[len(page.Lists)-1]
+			page.NextCursor = encodeCursor(cursor{Sort: sort, Value: lastValue, Id: last.Id})
+			break
+		}
+		page.Lists = append(page.Lists, l)
+		lastValue = value
+	}
+	if err := rows.Err(); err != nil {
+		return ListsPage{}, err
+	}
+	if err := getTags(db, page.Lists); err != nil {
+		return ListsPage{}, err
 	}
 	comms := make(map[int64]*community.Community)
-	for _, list := range ls {
-		log.Printf("Getting comm for list %v\n", list)
-		if list.Community != nil {
-			comm, ok := comms[list.Community.CommunityId]
-			if !ok {
-				err := GetCommunity(db, list.Community)
-				if err != nil {
-					return nil, err
-				}
-				comms[list.Community.CommunityId] = list.Community
-			} else {
-				list.Community = comm
-			}
-			log.Printf("Got comm %v\n", list.Community)
-		} else {
-			log.Printf("No comm")
+	for i := range page.Lists {
+		l := &page.Lists[i]
+		if l.Community == nil {
+			continue
 		}
+		if comm, ok := comms[l.Community.CommunityId]; ok {
+			l.Community = comm
+			continue
+		}
+		if err := GetCommunity(db, l.Community); err != nil {
+			return ListsPage{}, err
+		}
+		comms[l.Community.CommunityId] = l.Community
 	}
+	return page, nil
+}
 
-	return ls, nil
+// getTags loads the tags of the lists.
+func getTags(tx infra.Queryable, lists []List) error {
+	if len(lists) == 0 {
+		return nil
+	}
+	byId := make(map[int64]*List, len(lists))
+	placeholders := make([]string, 0, len(lists))
+	args := make([]any, 0, len(lists))
+	for i := range lists {
+		lists[i].Tags = make([]string, 0)
+		byId[lists[i].Id] = &lists[i]
+		placeholders = append(placeholders, "?")
+		args = append(args, lists[i].Id)
+	}
+	rows, err := tx.Query(`SELECT listId, tag FROM list_tag WHERE listId IN (`+strings.Join(placeholders, ", ")+`) ORDER BY tag`, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var listId int64
+		var tag string
+		if err := rows.Scan(&listId, &tag); err != nil {
+			return err
+		}
+		byId[listId].Tags = append(byId[listId].Tags, tag)
+	}
+	return rows.Err()
+}
+
+// FindTags implements ListsRepository.
+func (s *SqlListRepository) FindTags(userId int64) ([]string, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+  SELECT DISTINCT t.tag
+  FROM list_tag t
+  INNER JOIN list l ON l.listId = t.listId
+  WHERE `+visibleToUser+` AND l.deletedAt IS NULL
+  ORDER BY t.tag
+  `, userId, userId, userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	tags := make([]string, 0)
+	for rows.Next() {
+		var tag string
+		if err := rows.Scan(&tag); err != nil {
+			return nil, err
+		}
+		tags = append(tags, tag)
+	}
+	return tags, rows.Err()
 }
 
 // Update implements ListsRepository.
@@ -287,67 +983,365 @@ func (s *SqlListRepository) Update(list *List) (*List, error) {
 		return nil, err
 	}
 	defer tx.Rollback()
+	if err = updateList(tx, list); err != nil {
+		return nil, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
 
+func updateList(tx *sql.Tx, list *List) error {
 	list.UpdatedAt = time.Now()
-	_, err = tx.Exec(`
+	_, err := tx.Exec(`
         UPDATE list
         SET title = ?,
         description = ?,
-        updatedAt = ?
+        updatedAt = ?,
+        budget = ?,
+        currency = ?,
+        dueAt = ?,
+        recurrence = ?,
+        recurrenceMode = ?,
+        nextOccurrenceAt = ?,
+        seriesId = ?,
+        isTemplate = ?
         WHERE listId = ?
-    `, list.Title, list.Description, list.UpdatedAt, list.Id)
+    `, list.Title, list.Description, list.UpdatedAt, list.Budget, list.Currency, list.DueAt,
+		list.Recurrence, list.RecurrenceMode, list.NextOccurrenceAt, list.SeriesId, list.IsTemplate, list.Id)
 	if err != nil {
-		return nil, err
+		return err
+	}
+	// The owner changes when it is transferred
+	if list.Creator.Id != 0 {
+		if _, err = tx.Exec(`UPDATE list SET creatorLuserId = ? WHERE listId = ?`, list.Creator.Id, list.Id); err != nil {
+			return err
+		}
+	}
+	if err = saveGroups(tx, list); err != nil {
+		return err
 	}
 	_, err = tx.Exec(`
-        DELETE FROM list_groups
+        DELETE FROM list_colaborators
         WHERE listId = ?
     `, list.Id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	_, err = tx.Exec(`
-        DELETE FROM list_colaborators
-        WHERE listId = ?
+	println("Inserting colaborators")
+	for _, user := range list.Colaborators {
+		_, err = tx.Exec(`
+                INSERT INTO list_colaborators (listId, luserId, role)
+                VALUES (?, ?, ?)
+            `, list.Id, user.Id, list.RoleOf(user.Id))
+		if err != nil {
+			return err
+		}
+	}
+	if _, err = tx.Exec(`DELETE FROM list_tag WHERE listId = ?`, list.Id); err != nil {
+		return err
+	}
+	for _, tag := range list.Tags {
+		if _, err = tx.Exec(`INSERT INTO list_tag (listId, tag) VALUES (?, ?)`, list.Id, tag); err != nil {
+			return err
+		}
+	}
+	return indexList(tx, list.Id)
+}
+
+// saveGroups updates the groups and items of the list by id, so they keep it across saves.
+// The ones the list does not know yet are inserted and get their id written back,
+// and the ones it no longer has are deleted.
+func saveGroups(tx *sql.Tx, list *List) error {
+	savedGroups, err := queryIds(tx, `SELECT groupId FROM list_groups WHERE listId = ?`, list.Id)
+	if err != nil {
+		return err
+	}
+	savedItems, err := queryIds(tx, `
+        SELECT i.itemId
+        FROM list_group_items i
+        INNER JOIN list_groups g ON g.groupId = i.groupId
+        WHERE g.listId = ?
     `, list.Id)
 	if err != nil {
-		return nil, err
+		return err
 	}
-	for _, group := range list.Groups {
-		result, err := tx.Exec(`
-            INSERT INTO list_groups (listId, name)
-            VALUES (?, ?)
-        `, list.Id, group.Name)
+	keptGroups := make(map[int64]bool)
+	keptItems := make(map[int64]bool)
+	groups := make([]*Group, 0, len(list.Groups)+len(list.TrashedGroups))
+	groups = append(append(groups, list.Groups...), list.TrashedGroups...)
+	for _, group := range groups {
+		// Trashed groups that were never saved, or were purged meanwhile, are not kept
+		if group.DeletedAt != nil && !savedGroups[group.GroupId] {
+			continue
+		}
+		if savedGroups[group.GroupId] {
+			_, err = tx.Exec(`
+                UPDATE list_groups SET name = ?, deletedAt = ?
+                WHERE groupId = ?
+            `, group.Name, group.DeletedAt, group.GroupId)
+		} else {
+			var result sql.Result
+			result, err = tx.Exec(`
+                INSERT INTO list_groups (listId, name, deletedAt)
+                VALUES (?, ?, ?)
+            `, list.Id, group.Name, group.DeletedAt)
+			if err == nil {
+				group.GroupId, err = result.LastInsertId()
+			}
+		}
 		if err != nil {
+			return err
+		}
+		group.ListId = list.Id
+		keptGroups[group.GroupId] = true
+		for itemindex, item := range group.Items {
+			item.GroupId = group.GroupId
+			item.Order = int64(itemindex)
+			if savedItems[item.Id] {
+				_, err = tx.Exec(`
+                    UPDATE list_group_items
+                    SET groupId = ?, description = ?, quantity = ?, unit = ?, order_ = ?, checked = ?, unitPrice = ?, currency = ?, assigneeLuserId = ?, note = ?
+                    WHERE itemId = ?
+                `, item.GroupId, item.Description, item.Quantity, item.Unit, item.Order, item.Checked, item.UnitPrice, item.Currency, item.AssigneeId, item.Note, item.Id)
+			} else {
+				var result sql.Result
+				result, err = tx.Exec(`
+                    INSERT INTO list_group_items (groupId, description, quantity, unit, order_, checked, unitPrice, currency, assigneeLuserId, note)
+                    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+                `, item.GroupId, item.Description, item.Quantity, item.Unit, item.Order, item.Checked, item.UnitPrice, item.Currency, item.AssigneeId, item.Note)
+				if err == nil {
+					item.Id, err = result.LastInsertId()
+				}
+			}
+			if err != nil {
+				return err
+			}
+			keptItems[item.Id] = true
+		}
+	}
+	for itemId := range savedItems {
+		if !keptItems[itemId] {
+			if _, err = tx.Exec(`DELETE FROM list_group_items WHERE itemId = ?`, itemId); err != nil {
+				return err
+			}
+		}
+	}
+	for groupId := range savedGroups {
+		if !keptGroups[groupId] {
+			if _, err = tx.Exec(`DELETE FROM list_group_items WHERE groupId = ?`, groupId); err != nil {
+				return err
+			}
+			if _, err = tx.Exec(`DELETE FROM list_groups WHERE groupId = ?`, groupId); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// FindOccurrencesDue implements ListsRepository.
+func (s *SqlListRepository) FindOccurrencesDue(t time.Time) ([]int64, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// Occurrences are stored in UTC, so they compare as text
+	rows, err := db.Query(`
+  SELECT listId FROM list
+  WHERE recurrence != '' AND archivedAt IS NULL AND deletedAt IS NULL AND nextOccurrenceAt <= ?
+  `, t.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
 			return nil, err
 		}
-		groupId, err := result.LastInsertId()
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// Archive implements ListsRepository.
+func (s *SqlListRepository) Archive(listId int64, archivedAt time.Time) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+  UPDATE list
+  SET archivedAt = ?, recurrence = '', nextOccurrenceAt = NULL
+  WHERE listId = ?
+  `, archivedAt.UTC(), listId)
+	return err
+}
+
+// FindArchivedOccurrences implements ListsRepository.
+func (s *SqlListRepository) FindArchivedOccurrences(seriesId int64) ([]List, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+  SELECT `+listColumns+`
+  FROM list l
+  WHERE (l.seriesId = ? OR l.listId = ?) AND l.archivedAt IS NOT NULL AND l.deletedAt IS NULL
+  ORDER BY l.archivedAt DESC
+  `, seriesId, seriesId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ls := make([]List, 0)
+	for rows.Next() {
+		l, err := scanList(rows)
 		if err != nil {
 			return nil, err
 		}
-		for itemindex, item := range group.Items {
-			_, err = tx.Exec(`
-                INSERT INTO list_group_items (groupId, description, quantity, order_, checked)
-                VALUES (?, ?, ?, ?, ?)
-            `, groupId, item.Description, item.Quantity, itemindex, item.Checked)
+		ls = append(ls, l)
+	}
+	return ls, rows.Err()
+}
+
+// Duplicate implements ListsRepository.
+func (s *SqlListRepository) Duplicate(params *DuplicateParams) (List, error) {
+	source, err := s.Get(params.SourceId)
+	if err != nil {
+		return List{}, err
+	}
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return List{}, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return List{}, err
+	}
+	defer tx.Rollback()
+	result, err := tx.Exec(`
+    INSERT INTO list (title, description, creatorLuserId, communityId)
+    VALUES (?, ?, ?, ?)
+  `, params.Title, params.Description, params.CreatorId, params.CommunityId)
+	if err != nil {
+		return List{}, err
+	}
+	listId, err := result.LastInsertId()
+	if err != nil {
+		return List{}, err
+	}
+	creator := user.User{Id: params.CreatorId}
+	copied := &List{
+		Id:           listId,
+		Title:        params.Title,
+		Description:  params.Description,
+		Creator:      creator,
+		Colaborators: []user.User{creator},
+		Groups:       source.CopyGroups(params.CopyOptions),
+		Budget:       source.Budget,
+		Currency:     source.Currency,
+		IsTemplate:   params.IsTemplate,
+		Tags:         source.Tags,
+	}
+	if params.KeepColaborators {
+		copied.Colaborators = source.Colaborators
+		copied.ColaboratorRoles = source.ColaboratorRoles
+	}
+	if err = updateList(tx, copied); err != nil {
+		return List{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return List{}, err
+	}
+	return s.Get(listId)
+}
+
+// Merge implements ListsRepository.
+func (s *SqlListRepository) Merge(plan *MergePlan, userId int64) (List, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return List{}, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return List{}, err
+	}
+	defer tx.Rollback()
+	if err = updateList(tx, plan.Merged()); err != nil {
+		return List{}, err
+	}
+	// The items now have their ids in the target, the attachments of the source follow them
+	for _, group := range plan.Groups {
+		for _, item := range group.Items {
+			if item.FromSource == nil {
+				continue
+			}
+			_, err = tx.Exec(`UPDATE item_attachment SET itemId = ? WHERE itemId = ?`, item.Item.Id, item.FromSource.Id)
 			if err != nil {
-				return nil, err
+				return List{}, err
 			}
 		}
 	}
-	println("Inserting colaborators")
-	for _, user := range list.Colaborators {
-		_, err = tx.Exec(`
-                INSERT INTO list_colaborators (listId, luserId)
-                VALUES (?, ?)
-            `, list.Id, user.Id)
+	result, err := tx.Exec(`UPDATE list SET deletedAt = ? WHERE listId = ? AND creatorLuserId = ? AND deletedAt IS NULL`, time.Now().UTC(), plan.Source.Id, userId)
+	if err != nil {
+		return List{}, err
+	}
+	if err = expectAffected(result); err != nil {
+		return List{}, err
+	}
+	if err = tx.Commit(); err != nil {
+		return List{}, err
+	}
+	return s.Get(plan.Target.Id)
+}
+
+// FindTemplates implements ListsRepository.
+func (s *SqlListRepository) FindTemplates(userId int64) ([]List, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+  SELECT `+listColumns+`
+  FROM list l
+  WHERE l.isTemplate = 1 AND l.archivedAt IS NULL AND l.deletedAt IS NULL
+  AND (l.creatorLuserId = ?
+  OR l.communityId IN (SELECT c.communityId FROM community c WHERE c.createdByLuserId = ?)
+  OR l.communityId IN (SELECT m.communityId FROM community_members m WHERE m.memberId = ?))
+  ORDER BY l.title
+  `, userId, userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ls := make([]List, 0)
+	for rows.Next() {
+		l, err := scanList(rows)
 		if err != nil {
 			return nil, err
 		}
+		ls = append(ls, l)
 	}
-	err = tx.Commit()
-	if err != nil {
+	if err := rows.Err(); err != nil {
 		return nil, err
 	}
-	return list, nil
+	for _, l := range ls {
+		if l.Community != nil {
+			if err := GetCommunity(db, l.Community); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return ls, nil
 }
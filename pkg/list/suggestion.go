@@ -0,0 +1,116 @@
+package list
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// Suggestion is an item usually put on lists, to complete what is being typed.
+type Suggestion struct {
+	Description string    `json:"description"`
+	Quantity    float64   `json:"quantity"`
+	Unit        string    `json:"unit"`
+	GroupName   string    `json:"group"`
+	Uses        int       `json:"uses"`
+	LastUsedAt  time.Time `json:"lastUsedAt"`
+	score       float64
+}
+
+func (s Suggestion) QuantityWithUnit() units.Quantity {
+	return units.Quantity{Amount: s.Quantity, Unit: s.Unit}
+}
+
+// String shows the suggestion as "Milk (2 l)".
+func (s Suggestion) String() string {
+	return fmt.Sprintf("%s (%s)", s.Description, s.QuantityWithUnit())
+}
+
+// Text is what typing the suggestion would be, quantity included, e.g. "2 l Milk".
+func (s Suggestion) Text() string {
+	return s.QuantityWithUnit().String() + " " + s.Description
+}
+
+// SuggestionUse is an item found on a list, as evidence for a suggestion.
+type SuggestionUse struct {
+	Description string
+	Quantity    float64
+	Unit        string
+	GroupName   string
+	UsedAt      time.Time
+}
+
+// Half life of the weight of a use in the ranking of suggestions.
+const suggestionHalfLife = 30 * 24 * time.Hour
+
+// Descriptions given to new items and groups, which are not worth suggesting.
+var placeholderDescriptions = map[string]bool{"default": true, "new item": true}
+
+// IsPlaceholder tells whether the item still has the description it was created with.
+func (i *Item) IsPlaceholder() bool {
+	return placeholderDescriptions[strings.ToLower(strings.TrimSpace(i.Description))]
+}
+
+// RankSuggestions groups the uses by description, ranking the items by how often and how
+// recently they were used: each use weighs half as much every suggestionHalfLife.
+// The quantity and group suggested are the ones used the most, the most recent on a tie.
+func RankSuggestions(uses []SuggestionUse, now time.Time, limit int) []Suggestion {
+	type candidate struct {
+		Suggestion
+		quantities map[units.Quantity]int
+		groups     map[string]int
+	}
+	byKey := make(map[string]*candidate)
+	candidates := make([]*candidate, 0)
+	// Most recent first, so the first spelling, quantity and group seen win ties
+	sorted := make([]SuggestionUse, len(uses))
+	copy(sorted, uses)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].UsedAt.After(sorted[j].UsedAt) })
+	for _, use := range sorted {
+		description := strings.TrimSpace(use.Description)
+		key := strings.ToLower(description)
+		if key == "" || placeholderDescriptions[key] {
+			continue
+		}
+		c, ok := byKey[key]
+		if !ok {
+			c = &candidate{
+				Suggestion: Suggestion{Description: description, Quantity: use.Quantity, Unit: use.Unit, LastUsedAt: use.UsedAt},
+				quantities: make(map[units.Quantity]int),
+				groups:     make(map[string]int),
+			}
+			byKey[key] = c
+			candidates = append(candidates, c)
+		}
+		c.Uses++
+		age := now.Sub(use.UsedAt)
+		if age < 0 {
+			age = 0
+		}
+		c.score += math.Pow(0.5, float64(age)/float64(suggestionHalfLife))
+		quantity := units.Quantity{Amount: use.Quantity, Unit: use.Unit}
+		c.quantities[quantity]++
+		if c.quantities[quantity] > c.quantities[c.QuantityWithUnit()] {
+			c.Quantity, c.Unit = use.Quantity, use.Unit
+		}
+		if use.GroupName != "" && !placeholderDescriptions[strings.ToLower(use.GroupName)] {
+			c.groups[use.GroupName]++
+			if c.GroupName == "" || c.groups[use.GroupName] > c.groups[c.GroupName] {
+				c.GroupName = use.GroupName
+			}
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].score > candidates[j].score })
+	suggestions := make([]Suggestion, 0, min(limit, len(candidates)))
+	for _, c := range candidates {
+		if len(suggestions) == limit {
+			break
+		}
+		suggestions = append(suggestions, c.Suggestion)
+	}
+	return suggestions
+}
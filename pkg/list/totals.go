@@ -0,0 +1,82 @@
+package list
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+var currencyRegex = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// NormalizeCurrency validates an ISO 4217 like currency code, e.g. "BRL" or "usd".
+// An empty currency is valid.
+func NormalizeCurrency(currency string) (string, bool) {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if currency == "" {
+		return "", true
+	}
+	return currency, currencyRegex.MatchString(currency)
+}
+
+// Totals are the prices of a group or list by currency. Estimated counts every
+// item with a price, Paid only the checked ones.
+type Totals struct {
+	Estimated map[string]float64
+	Paid      map[string]float64
+}
+
+func newTotals() Totals {
+	return Totals{Estimated: make(map[string]float64), Paid: make(map[string]float64)}
+}
+
+func (t Totals) IsEmpty() bool {
+	return len(t.Estimated) == 0
+}
+
+func (t Totals) add(item *Item, defaultCurrency string) {
+	if item.UnitPrice == nil {
+		return
+	}
+	currency := item.Currency
+	if currency == "" {
+		currency = defaultCurrency
+	}
+	price := units.Round(item.Quantity * *item.UnitPrice)
+	t.Estimated[currency] = units.Round(t.Estimated[currency] + price)
+	if item.Checked != 0 {
+		t.Paid[currency] = units.Round(t.Paid[currency] + price)
+	}
+}
+
+// PriceTotals sums the prices of the items, using defaultCurrency for items without one.
+func (g *Group) PriceTotals(defaultCurrency string) Totals {
+	totals := newTotals()
+	for _, item := range g.Items {
+		totals.add(item, defaultCurrency)
+	}
+	return totals
+}
+
+func (l *List) PriceTotals() Totals {
+	totals := newTotals()
+	for _, group := range l.Groups {
+		for _, item := range group.Items {
+			totals.add(item, l.Currency)
+		}
+	}
+	return totals
+}
+
+// OverBudget tells whether the estimated total in the currency of the list exceeds its budget.
+func (l *List) OverBudget() bool {
+	if l.Budget == nil {
+		return false
+	}
+	return l.PriceTotals().Estimated[l.Currency] > *l.Budget
+}
+
+func FormatMoney(amount float64, currency string) string {
+	return strings.TrimSpace(fmt.Sprintf("%s %.2f", currency, amount))
+}
@@ -0,0 +1,67 @@
+package list
+
+import "time"
+
+// Trash holds what a user deleted and can still restore.
+type Trash struct {
+	// Lists created by the user
+	Lists []List
+	// Groups of the lists the user is a member of
+	Groups []TrashedGroup
+}
+
+type TrashedGroup struct {
+	ListId    int64
+	ListTitle string
+	Group     *Group
+}
+
+func (t *Trash) IsEmpty() bool {
+	return len(t.Lists) == 0 && len(t.Groups) == 0
+}
+
+// TrashGroup moves the group to the trash of the list.
+func (l *List) TrashGroup(groupId int64, deletedAt time.Time) *Group {
+	for i, group := range l.Groups {
+		if group.GroupId == groupId {
+			l.Groups = append(l.Groups[:i], l.Groups[i+1:]...)
+			deletedAt = deletedAt.UTC()
+			group.DeletedAt = &deletedAt
+			l.TrashedGroups = append(l.TrashedGroups, group)
+			return group
+		}
+	}
+	return nil
+}
+
+// RestoreGroup moves the group out of the trash, back to its position by id,
+// as groups are loaded in the order they were created.
+func (l *List) RestoreGroup(groupId int64) *Group {
+	for i, group := range l.TrashedGroups {
+		if group.GroupId == groupId {
+			l.TrashedGroups = append(l.TrashedGroups[:i], l.TrashedGroups[i+1:]...)
+			group.DeletedAt = nil
+			position := len(l.Groups)
+			for j, g := range l.Groups {
+				if g.GroupId > groupId {
+					position = j
+					break
+				}
+			}
+			l.Groups = append(l.Groups[:position], append([]*Group{group}, l.Groups[position:]...)...)
+			return group
+		}
+	}
+	return nil
+}
+
+// PurgeGroup forgets the group if it is in the trash.
+func (l *List) PurgeGroup(groupId int64) bool {
+	for i, group := range l.TrashedGroups {
+		if group.GroupId == groupId {
+			l.TrashedGroups = append(l.TrashedGroups[:i], l.TrashedGroups[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
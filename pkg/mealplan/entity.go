@@ -0,0 +1,126 @@
+// Package mealplan is the calendar of the meals of a community, from which the
+// ingredients to buy for a week are put on a list.
+package mealplan
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/recipe"
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// Layout of the days of meals, as they are stored and sent in forms.
+const DayLayout = "2006-01-02"
+
+const weekLayout = "Jan 2, 2006"
+
+type Meal struct {
+	Id          int64
+	CommunityId int64
+	Day         time.Time
+	// Written down by a member, or the title of the recipe
+	Title string
+	// Recipe whose ingredients are bought for the meal, nil for meals without one
+	RecipeId *int64
+	Servings int
+	// The recipe was deleted after the meal was planned, there is nothing to buy for it
+	RecipeDeleted bool
+	CreatorId     int64
+	CreatedAt     time.Time
+}
+
+var ErrNotFound = errors.New("meal not found")
+
+type Day struct {
+	Date  time.Time
+	Meals []Meal
+}
+
+// Week goes from monday to sunday.
+type Week struct {
+	Start time.Time
+	Days  []Day
+}
+
+// WeekOf returns the monday of the week of the day, at midnight UTC.
+func WeekOf(day time.Time) time.Time {
+	start := time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, 0, time.UTC)
+	// Sundays are the last day of the week, not the first
+	offset := (int(start.Weekday()) + 6) % 7
+	return start.AddDate(0, 0, -offset)
+}
+
+// NewWeek lays out the meals on the days of the week starting on the monday.
+func NewWeek(start time.Time, meals []Meal) *Week {
+	week := &Week{Start: start}
+	for i := 0; i < 7; i++ {
+		day := Day{Date: start.AddDate(0, 0, i), Meals: make([]Meal, 0)}
+		for _, meal := range meals {
+			if meal.Day.Equal(day.Date) {
+				day.Meals = append(day.Meals, meal)
+			}
+		}
+		week.Days = append(week.Days, day)
+	}
+	return week
+}
+
+// End is the day after the sunday of the week.
+func (w Week) End() time.Time {
+	return w.Start.AddDate(0, 0, 7)
+}
+
+func (w Week) Previous() string {
+	return w.Start.AddDate(0, 0, -7).Format(DayLayout)
+}
+
+func (w Week) Next() string {
+	return w.Start.AddDate(0, 0, 7).Format(DayLayout)
+}
+
+func (w Week) Title() string {
+	return "Week of " + w.Start.Format(weekLayout)
+}
+
+func (w Week) Meals() []Meal {
+	meals := make([]Meal, 0)
+	for _, day := range w.Days {
+		meals = append(meals, day.Meals...)
+	}
+	return meals
+}
+
+// Ingredients adds up the ingredients of the recipes of the meals, scaled to their servings.
+// Ingredients with the same description whose units can't be converted into each other are kept apart.
+func Ingredients(meals []Meal, recipes map[int64]recipe.Recipe) []recipe.Ingredient {
+	ingredients := make([]recipe.Ingredient, 0)
+	for _, meal := range meals {
+		if meal.RecipeId == nil {
+			continue
+		}
+		r, ok := recipes[*meal.RecipeId]
+		if !ok {
+			continue
+		}
+		for _, ingredient := range r.Scale(meal.Servings) {
+			ingredients = addIngredient(ingredients, ingredient)
+		}
+	}
+	return ingredients
+}
+
+func addIngredient(ingredients []recipe.Ingredient, ingredient recipe.Ingredient) []recipe.Ingredient {
+	for i := range ingredients {
+		if !strings.EqualFold(ingredients[i].Description, ingredient.Description) {
+			continue
+		}
+		if sum, ok := units.Sum(ingredients[i].Unit, ingredients[i].QuantityWithUnit(), ingredient.QuantityWithUnit()); ok {
+			ingredients[i].Quantity = sum.Amount
+			return ingredients
+		}
+	}
+	ingredient.Id = 0
+	return append(ingredients, ingredient)
+}
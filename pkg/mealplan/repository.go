@@ -0,0 +1,13 @@
+package mealplan
+
+import "time"
+
+type MealsRepository interface {
+	// FindBetween returns the meals of the community from the day to the one before until, by day.
+	FindBetween(communityId int64, from time.Time, until time.Time) ([]Meal, error)
+	// Get returns the meal or ErrNotFound.
+	Get(mealId int64) (Meal, error)
+	// Save inserts the meal when it has no id yet, writing it back, or updates it.
+	Save(meal *Meal) error
+	Delete(mealId int64) error
+}
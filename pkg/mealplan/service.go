@@ -0,0 +1,144 @@
+package mealplan
+
+import (
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/recipe"
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// LiveLists are the lists being edited, whose unsaved changes are the most recent state.
+type LiveLists interface {
+	// Edit applies the change to the list while nobody else changes it. When someone is editing
+	// the list, the change joins their unsaved changes, otherwise it is saved right away.
+	// The change returns whether it changed anything.
+	Edit(listId int64, change func(l *list.List) bool) error
+	// Save saves the unsaved changes of the list, when someone is editing it.
+	Save(listId int64) (*list.List, error)
+}
+
+type Service struct {
+	Meals   MealsRepository
+	Recipes recipe.RecipesRepository
+	Lists   list.ListsRepository
+	Live    LiveLists
+}
+
+// Week returns the meals of the community in the week starting on the monday.
+func (s *Service) Week(communityId int64, start time.Time) (*Week, error) {
+	meals, err := s.Meals.FindBetween(communityId, start, start.AddDate(0, 0, 7))
+	if err != nil {
+		return nil, err
+	}
+	return NewWeek(start, meals), nil
+}
+
+// Ingredients adds up the ingredients of the recipes of the meals of the week.
+func (s *Service) Ingredients(week *Week) ([]recipe.Ingredient, error) {
+	recipes := make(map[int64]recipe.Recipe)
+	for _, meal := range week.Meals() {
+		if meal.RecipeId == nil || meal.RecipeDeleted {
+			continue
+		}
+		if _, ok := recipes[*meal.RecipeId]; ok {
+			continue
+		}
+		r, err := s.Recipes.Get(*meal.RecipeId)
+		if err != nil {
+			return nil, err
+		}
+		recipes[r.Id] = r
+	}
+	return Ingredients(week.Meals(), recipes), nil
+}
+
+// ShoppingListParams describe the list the ingredients of a week are put on.
+type ShoppingListParams struct {
+	Week        *Week
+	CommunityId int64
+	// List to update, a new one of the community is created when 0
+	ListId    int64
+	CreatorId int64
+}
+
+// GenerateShoppingList puts on the list the ingredients of the meals of the week, less what
+// is already on it, in a group named after the week. It returns the id of the list.
+func (s *Service) GenerateShoppingList(params *ShoppingListParams) (int64, error) {
+	missing, err := s.Ingredients(params.Week)
+	if err != nil {
+		return 0, err
+	}
+	listId := params.ListId
+	if listId == 0 {
+		created, err := s.Lists.Create(&list.ListCreationParams{
+			Title:       "Meals of the week of " + params.Week.Start.Format(weekLayout),
+			CreatorId:   params.CreatorId,
+			CommunityId: &params.CommunityId,
+		})
+		if err != nil {
+			return 0, err
+		}
+		listId = created.Id
+	}
+	changed := false
+	err = s.Live.Edit(listId, func(current *list.List) bool {
+		for _, group := range current.Groups {
+			for _, item := range group.Items {
+				missing = subtract(missing, item)
+			}
+		}
+		if len(missing) == 0 {
+			return false
+		}
+		groupName := params.Week.Title()
+		var group *list.Group
+		for _, g := range current.Groups {
+			if strings.EqualFold(strings.TrimSpace(g.Name), groupName) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &list.Group{ListId: listId, Name: groupName}
+			current.Groups = append(current.Groups, group)
+		}
+		for _, ingredient := range missing {
+			group.AddItem(&list.Item{Description: ingredient.Description, Quantity: ingredient.Quantity, Unit: ingredient.Unit})
+		}
+		changed = true
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+	if !changed {
+		return listId, nil
+	}
+	// The ingredients are saved along with the unsaved changes of the editors
+	if _, err := s.Live.Save(listId); err != nil {
+		return 0, err
+	}
+	return listId, nil
+}
+
+// subtract takes the quantity of the item from the ingredient with the same description,
+// dropping the ingredient when the list already has enough of it.
+func subtract(ingredients []recipe.Ingredient, item *list.Item) []recipe.Ingredient {
+	for i, ingredient := range ingredients {
+		if !strings.EqualFold(strings.TrimSpace(item.Description), ingredient.Description) {
+			continue
+		}
+		amount, err := units.Convert(item.Quantity, item.Unit, ingredient.Unit)
+		if err != nil {
+			continue
+		}
+		ingredients[i].Quantity = units.Round(ingredient.Quantity - amount)
+		if ingredients[i].Quantity > 0 {
+			return ingredients
+		}
+		return append(ingredients[:i], ingredients[i+1:]...)
+	}
+	return ingredients
+}
@@ -0,0 +1,112 @@
+package mealplan
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlMealsRepository struct{}
+
+const mealColumns = `m.mealId, m.communityId, m.day, m.title, m.recipeId, m.servings,
+  m.recipeId IS NOT NULL AND r.recipeId IS NULL, m.creatorLuserId, m.createdAt`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanMeal(row scanner) (Meal, error) {
+	m := Meal{}
+	var day string
+	err := row.Scan(&m.Id, &m.CommunityId, &day, &m.Title, &m.RecipeId, &m.Servings, &m.RecipeDeleted, &m.CreatorId, &m.CreatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return m, ErrNotFound
+	} else if err != nil {
+		return m, err
+	}
+	m.Day, err = time.Parse(DayLayout, day)
+	return m, err
+}
+
+// FindBetween implements MealsRepository.
+func (s *SqlMealsRepository) FindBetween(communityId int64, from time.Time, until time.Time) ([]Meal, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT `+mealColumns+`
+    FROM meal m
+    LEFT JOIN recipe r ON r.recipeId = m.recipeId
+    WHERE m.communityId = ? AND m.day >= ? AND m.day < ?
+    ORDER BY m.day, m.mealId
+  `, communityId, from.Format(DayLayout), until.Format(DayLayout))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	meals := make([]Meal, 0)
+	for rows.Next() {
+		m, err := scanMeal(rows)
+		if err != nil {
+			return nil, err
+		}
+		meals = append(meals, m)
+	}
+	return meals, rows.Err()
+}
+
+// Get implements MealsRepository.
+func (s *SqlMealsRepository) Get(mealId int64) (Meal, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Meal{}, err
+	}
+	defer db.Close()
+	return scanMeal(db.QueryRow(`
+    SELECT `+mealColumns+`
+    FROM meal m
+    LEFT JOIN recipe r ON r.recipeId = m.recipeId
+    WHERE m.mealId = ?
+  `, mealId))
+}
+
+// Save implements MealsRepository.
+func (s *SqlMealsRepository) Save(meal *Meal) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if meal.Id != 0 {
+		_, err = db.Exec(`
+      UPDATE meal SET day = ?, title = ?, recipeId = ?, servings = ?
+      WHERE mealId = ?
+    `, meal.Day.Format(DayLayout), meal.Title, meal.RecipeId, meal.Servings, meal.Id)
+		return err
+	}
+	meal.CreatedAt = time.Now().UTC()
+	result, err := db.Exec(`
+    INSERT INTO meal (communityId, day, title, recipeId, servings, creatorLuserId, createdAt)
+    VALUES (?, ?, ?, ?, ?, ?, ?)
+  `, meal.CommunityId, meal.Day.Format(DayLayout), meal.Title, meal.RecipeId, meal.Servings, meal.CreatorId, meal.CreatedAt)
+	if err != nil {
+		return err
+	}
+	meal.Id, err = result.LastInsertId()
+	return err
+}
+
+// Delete implements MealsRepository.
+func (s *SqlMealsRepository) Delete(mealId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`DELETE FROM meal WHERE mealId = ?`, mealId)
+	return err
+}
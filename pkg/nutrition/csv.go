@@ -0,0 +1,81 @@
+package nutrition
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// Columns of the CSV files foods are imported from, in any order. Aliases are separated by "|",
+// the unit is a symbol from pkg/units, empty for foods counted in units.
+var csvColumns = []string{"name", "aliases", "per", "unit", "calories", "protein", "carbs", "fat"}
+
+// ParseCSV reads the foods of a CSV file whose first line names its columns.
+func ParseCSV(r io.Reader) ([]Food, error) {
+	reader := csv.NewReader(r)
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+	header, err := reader.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, errors.New("the CSV is empty")
+	} else if err != nil {
+		return nil, err
+	}
+	index := make(map[string]int)
+	for i, column := range header {
+		index[strings.ToLower(strings.TrimSpace(column))] = i
+	}
+	for _, column := range csvColumns {
+		if _, ok := index[column]; !ok {
+			return nil, fmt.Errorf("the CSV has no %q column", column)
+		}
+	}
+	foods := make([]Food, 0)
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			return foods, nil
+		} else if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		food, err := parseFood(record, index)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		foods = append(foods, food)
+	}
+}
+
+func parseFood(record []string, index map[string]int) (Food, error) {
+	food := Food{Name: strings.TrimSpace(record[index["name"]])}
+	if food.Name == "" {
+		return food, errors.New("name is required")
+	}
+	for _, alias := range strings.Split(record[index["aliases"]], "|") {
+		if alias = strings.TrimSpace(alias); alias != "" {
+			food.Aliases = append(food.Aliases, alias)
+		}
+	}
+	unit, ok := units.Normalize(strings.TrimSpace(record[index["unit"]]))
+	if !ok {
+		return food, fmt.Errorf("unknown unit %q", record[index["unit"]])
+	}
+	food.Unit = unit
+	amounts := []*float64{&food.Per, &food.Calories, &food.Protein, &food.Carbs, &food.Fat}
+	for i, column := range []string{"per", "calories", "protein", "carbs", "fat"} {
+		amount, err := units.ParseAmount(record[index[column]])
+		if err != nil {
+			return food, fmt.Errorf("invalid %s: %w", column, err)
+		}
+		*amounts[i] = amount
+	}
+	if food.Per == 0 {
+		return food, errors.New("per should be more than 0")
+	}
+	return food, nil
+}
@@ -0,0 +1,206 @@
+// Package nutrition is the catalogue of the nutrition facts of foods, matched to the
+// descriptions of items to estimate what a list or a meal plan provides.
+package nutrition
+
+import (
+	"fmt"
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/recipe"
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+// Facts are the energy, in kcal, and the macros, in grams, of an amount of food.
+type Facts struct {
+	Calories float64
+	Protein  float64
+	Carbs    float64
+	Fat      float64
+}
+
+func (f Facts) Add(other Facts) Facts {
+	return Facts{
+		Calories: f.Calories + other.Calories,
+		Protein:  f.Protein + other.Protein,
+		Carbs:    f.Carbs + other.Carbs,
+		Fat:      f.Fat + other.Fat,
+	}
+}
+
+func (f Facts) Times(factor float64) Facts {
+	return Facts{
+		Calories: f.Calories * factor,
+		Protein:  f.Protein * factor,
+		Carbs:    f.Carbs * factor,
+		Fat:      f.Fat * factor,
+	}
+}
+
+func (f Facts) IsZero() bool {
+	return f == Facts{}
+}
+
+type Food struct {
+	Id   int64
+	Name string
+	// Other descriptions items of the food are written with
+	Aliases []string
+	// The facts are for this amount of the unit, e.g. 100 g or 1 unit
+	Per  float64
+	Unit string
+	Facts
+}
+
+// FactsOf returns the facts of an amount of the food, failing when the unit can't be converted to the one of the food.
+func (f Food) FactsOf(quantity float64, unit string) (Facts, bool) {
+	amount, err := units.Convert(quantity, unit, f.Unit)
+	if err != nil || f.Per <= 0 {
+		return Facts{}, false
+	}
+	return f.Facts.Times(amount / f.Per), true
+}
+
+// Catalogue finds the foods by their names and aliases, regardless of case.
+type Catalogue struct {
+	byName map[string]*Food
+}
+
+func NewCatalogue(foods []Food) *Catalogue {
+	c := &Catalogue{byName: make(map[string]*Food)}
+	for i := range foods {
+		for _, name := range append([]string{foods[i].Name}, foods[i].Aliases...) {
+			if key := matchKey(name); key != "" {
+				c.byName[key] = &foods[i]
+			}
+		}
+	}
+	return c
+}
+
+// Match returns the food an item description is about, also trying it in the singular.
+func (c *Catalogue) Match(description string) (*Food, bool) {
+	key := matchKey(description)
+	candidates := []string{key}
+	if strings.HasSuffix(key, "es") {
+		candidates = append(candidates, strings.TrimSuffix(key, "es"))
+	}
+	if strings.HasSuffix(key, "s") {
+		candidates = append(candidates, strings.TrimSuffix(key, "s"))
+	}
+	for _, candidate := range candidates {
+		if food, ok := c.byName[candidate]; ok {
+			return food, true
+		}
+	}
+	return nil, false
+}
+
+func matchKey(description string) string {
+	return strings.Join(strings.Fields(strings.ToLower(description)), " ")
+}
+
+type EstimatedItem struct {
+	Description string
+	Quantity    units.Quantity
+	Food        *Food
+	Facts
+}
+
+// Estimate is what the items matched to foods of the catalogue provide.
+type Estimate struct {
+	Total Facts
+	Items []EstimatedItem
+	// Descriptions of the items that matched no food, or in units the food can't be converted from
+	Unknown []string
+}
+
+func (e *Estimate) add(c *Catalogue, description string, quantity float64, unit string) {
+	description = strings.TrimSpace(description)
+	if description == "" {
+		return
+	}
+	food, ok := c.Match(description)
+	if !ok {
+		e.Unknown = append(e.Unknown, description)
+		return
+	}
+	facts, ok := food.FactsOf(quantity, unit)
+	if !ok {
+		e.Unknown = append(e.Unknown, description)
+		return
+	}
+	e.Total = e.Total.Add(facts)
+	e.Items = append(e.Items, EstimatedItem{
+		Description: description,
+		Quantity:    units.Quantity{Amount: quantity, Unit: unit},
+		Food:        food,
+		Facts:       facts,
+	})
+}
+
+// EstimateList estimates the items of the list, whether they were bought or not.
+func (c *Catalogue) EstimateList(l *list.List) *Estimate {
+	e := &Estimate{Items: make([]EstimatedItem, 0), Unknown: make([]string, 0)}
+	for _, group := range l.Groups {
+		for _, item := range group.Items {
+			if item.IsPlaceholder() {
+				continue
+			}
+			e.add(c, item.Description, item.Quantity, item.Unit)
+		}
+	}
+	return e
+}
+
+// EstimateIngredients estimates ingredients, such as those of the meals of a week.
+func (c *Catalogue) EstimateIngredients(ingredients []recipe.Ingredient) *Estimate {
+	e := &Estimate{Items: make([]EstimatedItem, 0), Unknown: make([]string, 0)}
+	for _, ingredient := range ingredients {
+		e.add(c, ingredient.Description, ingredient.Quantity, ingredient.Unit)
+	}
+	return e
+}
+
+// Target is what the members of a community aim to eat in a day, all of them together.
+type Target struct {
+	CommunityId int64
+	Daily       Facts
+}
+
+// Comparison is one of the facts of an estimate next to its target.
+type Comparison struct {
+	Name      string
+	Unit      string
+	Estimated float64
+	Target    float64
+}
+
+func (c Comparison) Percent() int {
+	if c.Target <= 0 {
+		return 0
+	}
+	return int(c.Estimated * 100 / c.Target)
+}
+
+func (c Comparison) String() string {
+	return fmt.Sprintf("%.0f of %.0f %s", c.Estimated, c.Target, c.Unit)
+}
+
+// Compare puts the estimate next to the daily targets for the number of days, leaving out the facts without a target.
+func (t Target) Compare(estimate Facts, days int) []Comparison {
+	target := t.Daily.Times(float64(days))
+	comparisons := []Comparison{
+		{Name: "Calories", Unit: "kcal", Estimated: estimate.Calories, Target: target.Calories},
+		{Name: "Protein", Unit: "g", Estimated: estimate.Protein, Target: target.Protein},
+		{Name: "Carbs", Unit: "g", Estimated: estimate.Carbs, Target: target.Carbs},
+		{Name: "Fat", Unit: "g", Estimated: estimate.Fat, Target: target.Fat},
+	}
+	result := make([]Comparison, 0, len(comparisons))
+	for _, c := range comparisons {
+		if c.Target > 0 {
+			result = append(result, c)
+		}
+	}
+	return result
+}
@@ -0,0 +1,10 @@
+package nutrition
+
+type NutritionRepository interface {
+	// Import inserts the foods, replacing the ones with the same name, and returns how many there were.
+	Import(foods []Food) (int, error)
+	FindFoods() ([]Food, error)
+	// GetTarget returns the daily target of the community, zero when it was never set.
+	GetTarget(communityId int64) (Target, error)
+	SaveTarget(target *Target) error
+}
@@ -0,0 +1,108 @@
+package nutrition
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlNutritionRepository struct{}
+
+// Import implements NutritionRepository.
+func (s *SqlNutritionRepository) Import(foods []Food) (int, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return 0, err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.Prepare(`
+    INSERT INTO nutrition_food (name, aliases, per, unit, calories, protein, carbs, fat)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+    ON CONFLICT (name) DO UPDATE SET aliases = excluded.aliases, per = excluded.per, unit = excluded.unit,
+      calories = excluded.calories, protein = excluded.protein, carbs = excluded.carbs, fat = excluded.fat
+  `)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+	for _, food := range foods {
+		_, err := stmt.Exec(food.Name, strings.Join(food.Aliases, "|"), food.Per, food.Unit, food.Calories, food.Protein, food.Carbs, food.Fat)
+		if err != nil {
+			return 0, err
+		}
+	}
+	return len(foods), tx.Commit()
+}
+
+// FindFoods implements NutritionRepository.
+func (s *SqlNutritionRepository) FindFoods() ([]Food, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT foodId, name, aliases, per, unit, calories, protein, carbs, fat
+    FROM nutrition_food
+    ORDER BY name
+  `)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	foods := make([]Food, 0)
+	for rows.Next() {
+		f := Food{}
+		var aliases string
+		if err := rows.Scan(&f.Id, &f.Name, &aliases, &f.Per, &f.Unit, &f.Calories, &f.Protein, &f.Carbs, &f.Fat); err != nil {
+			return nil, err
+		}
+		if aliases != "" {
+			f.Aliases = strings.Split(aliases, "|")
+		}
+		foods = append(foods, f)
+	}
+	return foods, rows.Err()
+}
+
+// GetTarget implements NutritionRepository.
+func (s *SqlNutritionRepository) GetTarget(communityId int64) (Target, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Target{}, err
+	}
+	defer db.Close()
+	t := Target{CommunityId: communityId}
+	err = db.QueryRow(`
+    SELECT calories, protein, carbs, fat
+    FROM nutrition_target
+    WHERE communityId = ?
+  `, communityId).Scan(&t.Daily.Calories, &t.Daily.Protein, &t.Daily.Carbs, &t.Daily.Fat)
+	if errors.Is(err, sql.ErrNoRows) {
+		return t, nil
+	}
+	return t, err
+}
+
+// SaveTarget implements NutritionRepository.
+func (s *SqlNutritionRepository) SaveTarget(target *Target) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+    INSERT INTO nutrition_target (communityId, calories, protein, carbs, fat, updatedAt) VALUES (?, ?, ?, ?, ?, ?)
+    ON CONFLICT (communityId) DO UPDATE SET calories = excluded.calories, protein = excluded.protein,
+      carbs = excluded.carbs, fat = excluded.fat, updatedAt = excluded.updatedAt
+  `, target.CommunityId, target.Daily.Calories, target.Daily.Protein, target.Daily.Carbs, target.Daily.Fat, time.Now().UTC())
+	return err
+}
@@ -0,0 +1,71 @@
+// Package pantry keeps what a community has at home, restocked with what is bought
+// from the lists and refilled through a shopping list when it runs low.
+package pantry
+
+import (
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+type Pantry struct {
+	CommunityId int64
+	// List where the items running low are added, nil when they are not
+	ShoppingListId *int64
+	Items          []Item
+}
+
+// IsShoppingList tells whether the items running low are added to the list.
+func (p Pantry) IsShoppingList(listId int64) bool {
+	return p.ShoppingListId != nil && *p.ShoppingListId == listId
+}
+
+type Item struct {
+	Id          int64
+	CommunityId int64
+	Description string
+	Quantity    float64
+	// Symbol of a unit from pkg/units, the minimum is in it too
+	Unit string
+	// Stock below which the item is added to the shopping list
+	Minimum   float64
+	UpdatedAt time.Time
+}
+
+var (
+	ErrNotFound     = errors.New("pantry item not found")
+	ErrIncompatible = errors.New("the quantity cannot be converted to the unit of the pantry item")
+)
+
+func (i Item) QuantityWithUnit() units.Quantity {
+	return units.Quantity{Amount: i.Quantity, Unit: i.Unit}
+}
+
+func (i Item) MinimumWithUnit() units.Quantity {
+	return units.Quantity{Amount: i.Minimum, Unit: i.Unit}
+}
+
+// Low tells whether the stock is below the minimum.
+func (i Item) Low() bool {
+	return i.Quantity < i.Minimum
+}
+
+// Missing is how much to buy to get back to the minimum.
+func (i Item) Missing() float64 {
+	if !i.Low() {
+		return 0
+	}
+	return units.Round(i.Minimum - i.Quantity)
+}
+
+// add changes the stock by an amount written in any unit convertible to the one of the item,
+// never going below zero.
+func (i *Item) add(amount float64, unit string) error {
+	converted, err := units.Convert(amount, unit, i.Unit)
+	if err != nil {
+		return ErrIncompatible
+	}
+	i.Quantity = max(units.Round(i.Quantity+converted), 0)
+	return nil
+}
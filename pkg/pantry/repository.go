@@ -0,0 +1,14 @@
+package pantry
+
+type PantryRepository interface {
+	// Get returns the pantry of the community, empty when it was never set up.
+	Get(communityId int64) (Pantry, error)
+	// GetItem returns the item or ErrNotFound.
+	GetItem(itemId int64) (Item, error)
+	// FindItem returns the item of the community with the description regardless of case, or ErrNotFound.
+	FindItem(communityId int64, description string) (Item, error)
+	// SaveItem inserts the item when it has no id yet, writing it back, or updates it.
+	SaveItem(item *Item) error
+	DeleteItem(itemId int64) error
+	SetShoppingList(communityId int64, listId *int64) error
+}
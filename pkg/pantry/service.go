@@ -0,0 +1,140 @@
+package pantry
+
+import (
+	"errors"
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/list"
+)
+
+// LiveLists are the lists being edited, whose unsaved changes are the most recent state.
+type LiveLists interface {
+	// Edit applies the change to the list while nobody else changes it. When someone is editing
+	// the list, the change joins their unsaved changes, otherwise it is saved right away.
+	// The change returns whether it changed anything.
+	Edit(listId int64, change func(l *list.List) bool) error
+}
+
+// Name of the group the items running low are added to.
+const RefillGroupName = "Pantry"
+
+type Service struct {
+	Repository PantryRepository
+	// Set up in main, as the live editor is created after the service
+	Live LiveLists
+}
+
+// Restock adds what was bought to the item of the pantry with the same description.
+// Items the pantry does not keep, or bought in units that can't be converted, are ignored.
+func (s *Service) Restock(communityId int64, description string, quantity float64, unit string) error {
+	item, err := s.findConvertible(communityId, description, quantity, unit)
+	if item == nil || err != nil {
+		return err
+	}
+	// Stock going up never falls below the minimum, there is nothing to refill
+	return s.Repository.SaveItem(item)
+}
+
+// Unstock takes back a restock, such as when an item is unchecked right after being checked.
+func (s *Service) Unstock(communityId int64, description string, quantity float64, unit string) error {
+	item, err := s.findConvertible(communityId, description, -quantity, unit)
+	if item == nil || err != nil {
+		return err
+	}
+	return s.Save(item)
+}
+
+// findConvertible returns the item of the pantry with the quantity added, or nil when there is none to change.
+func (s *Service) findConvertible(communityId int64, description string, quantity float64, unit string) (*Item, error) {
+	item, err := s.Repository.FindItem(communityId, description)
+	if errors.Is(err, ErrNotFound) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err = item.add(quantity, unit); errors.Is(err, ErrIncompatible) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// Use takes from the stock of the item an amount in its unit.
+func (s *Service) Use(item *Item, quantity float64) error {
+	if err := item.add(-quantity, item.Unit); err != nil {
+		return err
+	}
+	return s.Save(item)
+}
+
+// Save keeps the item, adding it to the shopping list of the pantry when it is running low.
+func (s *Service) Save(item *Item) error {
+	if err := s.Repository.SaveItem(item); err != nil {
+		return err
+	}
+	if !item.Low() {
+		return nil
+	}
+	pantry, err := s.Repository.Get(item.CommunityId)
+	if err != nil {
+		return err
+	}
+	if pantry.ShoppingListId == nil {
+		return nil
+	}
+	return s.refill(*pantry.ShoppingListId, item)
+}
+
+// refill adds what is missing of the item to the list, unless it is already there to buy.
+func (s *Service) refill(listId int64, item *Item) error {
+	return s.Live.Edit(listId, func(current *list.List) bool {
+		if current.DeletedAt != nil {
+			return false
+		}
+		var refillGroup *list.Group
+		for _, group := range current.Groups {
+			for _, i := range group.Items {
+				if i.Checked == 0 && strings.EqualFold(strings.TrimSpace(i.Description), item.Description) {
+					return false
+				}
+			}
+			if refillGroup == nil && strings.EqualFold(group.Name, RefillGroupName) {
+				refillGroup = group
+			}
+		}
+		if refillGroup == nil {
+			refillGroup = &list.Group{ListId: listId, Name: RefillGroupName}
+			current.Groups = append(current.Groups, refillGroup)
+		}
+		refillGroup.Items = append(refillGroup.Items, &list.Item{
+			Description: item.Description,
+			Quantity:    item.Missing(),
+			Unit:        item.Unit,
+		})
+		return true
+	})
+}
+
+// SetShoppingList designates where the items running low are added, adding the ones already low.
+func (s *Service) SetShoppingList(communityId int64, listId *int64) error {
+	if err := s.Repository.SetShoppingList(communityId, listId); err != nil {
+		return err
+	}
+	if listId == nil {
+		return nil
+	}
+	pantry, err := s.Repository.Get(communityId)
+	if err != nil {
+		return err
+	}
+	for _, item := range pantry.Items {
+		if !item.Low() {
+			continue
+		}
+		if err := s.refill(*listId, &item); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,129 @@
+package pantry
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlPantryRepository struct{}
+
+const itemColumns = `pantryItemId, communityId, description, quantity, unit, minimum, updatedAt`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanItem(row scanner) (Item, error) {
+	i := Item{}
+	err := row.Scan(&i.Id, &i.CommunityId, &i.Description, &i.Quantity, &i.Unit, &i.Minimum, &i.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return i, ErrNotFound
+	}
+	return i, err
+}
+
+// Get implements PantryRepository.
+func (s *SqlPantryRepository) Get(communityId int64) (Pantry, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Pantry{}, err
+	}
+	defer db.Close()
+	pantry := Pantry{CommunityId: communityId, Items: make([]Item, 0)}
+	err = db.QueryRow(`SELECT shoppingListId FROM pantry WHERE communityId = ?`, communityId).Scan(&pantry.ShoppingListId)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return Pantry{}, err
+	}
+	rows, err := db.Query(`SELECT `+itemColumns+` FROM pantry_item WHERE communityId = ? ORDER BY description COLLATE NOCASE`, communityId)
+	if err != nil {
+		return Pantry{}, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		item, err := scanItem(rows)
+		if err != nil {
+			return Pantry{}, err
+		}
+		pantry.Items = append(pantry.Items, item)
+	}
+	return pantry, rows.Err()
+}
+
+// GetItem implements PantryRepository.
+func (s *SqlPantryRepository) GetItem(itemId int64) (Item, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Item{}, err
+	}
+	defer db.Close()
+	return scanItem(db.QueryRow(`SELECT `+itemColumns+` FROM pantry_item WHERE pantryItemId = ?`, itemId))
+}
+
+// FindItem implements PantryRepository.
+func (s *SqlPantryRepository) FindItem(communityId int64, description string) (Item, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Item{}, err
+	}
+	defer db.Close()
+	return scanItem(db.QueryRow(`
+    SELECT `+itemColumns+` FROM pantry_item
+    WHERE communityId = ? AND description = ? COLLATE NOCASE
+  `, communityId, strings.TrimSpace(description)))
+}
+
+// SaveItem implements PantryRepository.
+func (s *SqlPantryRepository) SaveItem(item *Item) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	item.Description = strings.TrimSpace(item.Description)
+	item.UpdatedAt = time.Now().UTC()
+	if item.Id != 0 {
+		_, err = db.Exec(`
+      UPDATE pantry_item SET description = ?, quantity = ?, unit = ?, minimum = ?, updatedAt = ?
+      WHERE pantryItemId = ?
+    `, item.Description, item.Quantity, item.Unit, item.Minimum, item.UpdatedAt, item.Id)
+		return err
+	}
+	result, err := db.Exec(`
+    INSERT INTO pantry_item (communityId, description, quantity, unit, minimum, updatedAt)
+    VALUES (?, ?, ?, ?, ?, ?)
+  `, item.CommunityId, item.Description, item.Quantity, item.Unit, item.Minimum, item.UpdatedAt)
+	if err != nil {
+		return err
+	}
+	item.Id, err = result.LastInsertId()
+	return err
+}
+
+// DeleteItem implements PantryRepository.
+func (s *SqlPantryRepository) DeleteItem(itemId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`DELETE FROM pantry_item WHERE pantryItemId = ?`, itemId)
+	return err
+}
+
+// SetShoppingList implements PantryRepository.
+func (s *SqlPantryRepository) SetShoppingList(communityId int64, listId *int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+    INSERT INTO pantry (communityId, shoppingListId) VALUES (?, ?)
+    ON CONFLICT (communityId) DO UPDATE SET shoppingListId = excluded.shoppingListId
+  `, communityId, listId)
+	return err
+}
@@ -0,0 +1,125 @@
+// Package purchase keeps what was bought from the lists, so habits can be told
+// after the lists are reset or deleted.
+package purchase
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/units"
+	"vilmasoftware.com/colablists/pkg/user"
+)
+
+// Purchase is an item checked on a list.
+type Purchase struct {
+	Id          int64
+	ListId      int64
+	CommunityId *int64
+	// Id of the item when it was checked, it may no longer exist
+	ItemId      int64
+	Buyer       user.User
+	Description string
+	Quantity    float64
+	Unit        string
+	// Price of one unit, nil when it was not known
+	UnitPrice *float64
+	Currency  string
+	// In UTC
+	PurchasedAt time.Time
+}
+
+func (p Purchase) QuantityWithUnit() units.Quantity {
+	return units.Quantity{Amount: p.Quantity, Unit: p.Unit}
+}
+
+// Price is what was paid for the purchase, nil when the unit price was not known.
+func (p Purchase) Price() *float64 {
+	if p.UnitPrice == nil {
+		return nil
+	}
+	price := units.Round(*p.UnitPrice * p.Quantity)
+	return &price
+}
+
+// ItemStats sums up the purchases of an item, told apart by its description regardless of case.
+type ItemStats struct {
+	Description string
+	Purchases   int
+	Quantities  []units.Quantity
+	FirstAt     time.Time
+	LastAt      time.Time
+	// Distinct days the item was bought on, as it is often checked on a few lists at once
+	Days int
+}
+
+// Interval is the average time between the days the item is bought, zero when bought on a single day.
+func (s ItemStats) Interval() time.Duration {
+	if s.Days < 2 {
+		return 0
+	}
+	return s.LastAt.Sub(s.FirstAt) / time.Duration(s.Days-1)
+}
+
+// Every tells how often the item is bought, e.g. "every 5 days", empty when it can't be told.
+func (s ItemStats) Every() string {
+	interval := s.Interval()
+	if interval == 0 {
+		return ""
+	}
+	days := int(math.Round(interval.Hours() / 24))
+	switch {
+	case days <= 1:
+		return "every day"
+	case days < 14:
+		return fmt.Sprintf("every %d days", days)
+	}
+	return fmt.Sprintf("every %d weeks", int(math.Round(float64(days)/7)))
+}
+
+// Summarize returns the stats of the items bought, the most bought first, at most limit.
+// The description of an item is the one it was last bought with.
+func Summarize(purchases []Purchase, limit int) []ItemStats {
+	sorted := make([]Purchase, len(purchases))
+	copy(sorted, purchases)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].PurchasedAt.After(sorted[j].PurchasedAt)
+	})
+	byKey := make(map[string]*ItemStats)
+	quantities := make(map[string][]units.Quantity)
+	days := make(map[string]map[string]bool)
+	order := make([]string, 0)
+	for _, p := range sorted {
+		key := strings.ToLower(strings.TrimSpace(p.Description))
+		if key == "" {
+			continue
+		}
+		stats, ok := byKey[key]
+		if !ok {
+			stats = &ItemStats{Description: strings.TrimSpace(p.Description), LastAt: p.PurchasedAt}
+			byKey[key] = stats
+			days[key] = make(map[string]bool)
+			order = append(order, key)
+		}
+		stats.Purchases++
+		stats.FirstAt = p.PurchasedAt
+		quantities[key] = append(quantities[key], p.QuantityWithUnit())
+		days[key][p.PurchasedAt.Format(time.DateOnly)] = true
+	}
+	result := make([]ItemStats, 0, len(order))
+	for _, key := range order {
+		stats := byKey[key]
+		stats.Quantities = units.Aggregate(quantities[key])
+		stats.Days = len(days[key])
+		result = append(result, *stats)
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Purchases > result[j].Purchases
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
@@ -0,0 +1,14 @@
+package purchase
+
+import "time"
+
+type PurchasesRepository interface {
+	Record(purchase *Purchase) error
+	// Undo forgets the last purchase of the item on the list recorded after since,
+	// for items unchecked right after being checked. It returns nil when there was none.
+	Undo(listId int64, itemId int64, since time.Time) (*Purchase, error)
+	// FindByBuyer returns the purchases of the user since the given time, most recent first.
+	FindByBuyer(userId int64, since time.Time) ([]Purchase, error)
+	// FindByCommunity returns the purchases from the lists of the community since the given time, most recent first.
+	FindByCommunity(communityId int64, since time.Time) ([]Purchase, error)
+}
@@ -0,0 +1,99 @@
+package purchase
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlPurchasesRepository struct{}
+
+// Record implements PurchasesRepository.
+func (s *SqlPurchasesRepository) Record(purchase *Purchase) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	purchase.PurchasedAt = purchase.PurchasedAt.UTC()
+	result, err := db.Exec(`
+    INSERT INTO purchase (listId, communityId, itemId, buyerLuserId, description, quantity, unit, unitPrice, currency, purchasedAt)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+  `, purchase.ListId, purchase.CommunityId, purchase.ItemId, purchase.Buyer.Id, purchase.Description, purchase.Quantity,
+		purchase.Unit, purchase.UnitPrice, purchase.Currency, purchase.PurchasedAt)
+	if err != nil {
+		return err
+	}
+	purchase.Id, err = result.LastInsertId()
+	return err
+}
+
+// Undo implements PurchasesRepository.
+func (s *SqlPurchasesRepository) Undo(listId int64, itemId int64, since time.Time) (*Purchase, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	p := &Purchase{}
+	err = db.QueryRow(`
+    SELECT purchaseId, listId, communityId, itemId, buyerLuserId, description, quantity, unit, unitPrice, currency, purchasedAt
+    FROM purchase
+    WHERE listId = ? AND itemId = ? AND purchasedAt > ?
+    ORDER BY purchasedAt DESC
+    LIMIT 1
+  `, listId, itemId, since.UTC()).Scan(&p.Id, &p.ListId, &p.CommunityId, &p.ItemId, &p.Buyer.Id, &p.Description, &p.Quantity,
+		&p.Unit, &p.UnitPrice, &p.Currency, &p.PurchasedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if _, err = db.Exec(`DELETE FROM purchase WHERE purchaseId = ?`, p.Id); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// FindByBuyer implements PurchasesRepository.
+func (s *SqlPurchasesRepository) FindByBuyer(userId int64, since time.Time) ([]Purchase, error) {
+	return s.find(`p.buyerLuserId = ?`, userId, since)
+}
+
+// FindByCommunity implements PurchasesRepository.
+func (s *SqlPurchasesRepository) FindByCommunity(communityId int64, since time.Time) ([]Purchase, error) {
+	return s.find(`p.communityId = ?`, communityId, since)
+}
+
+func (s *SqlPurchasesRepository) find(where string, id int64, since time.Time) ([]Purchase, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT p.purchaseId, p.listId, p.communityId, p.itemId, p.buyerLuserId, COALESCE(u.username, ''), COALESCE(u.avatarUrl, ''),
+      p.description, p.quantity, p.unit, p.unitPrice, p.currency, p.purchasedAt
+    FROM purchase p
+    LEFT JOIN luser u ON u.luserId = p.buyerLuserId
+    WHERE `+where+` AND p.purchasedAt > ?
+    ORDER BY p.purchasedAt DESC
+  `, id, since.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	purchases := make([]Purchase, 0)
+	for rows.Next() {
+		p := Purchase{}
+		err := rows.Scan(&p.Id, &p.ListId, &p.CommunityId, &p.ItemId, &p.Buyer.Id, &p.Buyer.Username, &p.Buyer.AvatarUrl,
+			&p.Description, &p.Quantity, &p.Unit, &p.UnitPrice, &p.Currency, &p.PurchasedAt)
+		if err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+	return purchases, rows.Err()
+}
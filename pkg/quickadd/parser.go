@@ -0,0 +1,253 @@
+// Package quickadd extracts quantity, unit and description from items typed in
+// free text, such as "2 kg tomatoes", "3x milk", "half dozen eggs" or
+// "meia dúzia de ovos".
+package quickadd
+
+import (
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"vilmasoftware.com/colablists/pkg/units"
+)
+
+type Item struct {
+	Quantity    float64
+	Unit        string
+	Description string
+	// Whether the text had a quantity. When false Quantity is 1 and Description is the whole text.
+	HasQuantity bool
+}
+
+var (
+	decimalRegex  = regexp.MustCompile(`^\d+(?:[.,]\d+)?$`)
+	fractionRegex = regexp.MustCompile(`^(\d+)/(\d+)$`)
+	// A number glued to a unit or to the "x" marker, e.g. "500g", "1,5l", "3x".
+	numberSuffixRegex = regexp.MustCompile(`^(\d+(?:[.,]\d+)?|\d*[½¼¾⅓⅔⅛])(\p{L}+)$`)
+)
+
+// Parse reads a quantity and unit from the start of the text ("2 kg tomatoes")
+// or, when there is none, from its end ("tomatoes 2 kg", "milk x3").
+func Parse(text string) Item {
+	fields := strings.Fields(text)
+	if len(fields) == 0 {
+		return Item{Quantity: 1}
+	}
+	words := make([]string, len(fields))
+	for i, field := range fields {
+		words[i] = strings.ToLower(field)
+	}
+	if item, ok := parseLeading(fields, words); ok {
+		return item
+	}
+	if item, ok := parseTrailing(fields, words); ok {
+		return item
+	}
+	return Item{Quantity: 1, Description: strings.Join(fields, " ")}
+}
+
+func parseLeading(fields, words []string) (Item, bool) {
+	phrase, ok := readQuantity(words)
+	if !ok {
+		return Item{}, false
+	}
+	n := phrase.words
+	if n < len(words) && connectorWords[words[n]] {
+		n++
+	}
+	if n >= len(fields) {
+		return Item{}, false
+	}
+	return Item{
+		Quantity:    units.Round(phrase.quantity),
+		Unit:        phrase.unit,
+		Description: strings.Join(fields[n:], " "),
+		HasQuantity: true,
+	}, true
+}
+
+// parseTrailing only accepts quantities with a unit or an "x" marker, so that
+// names ending in numbers ("iphone 15") are left alone.
+func parseTrailing(fields, words []string) (Item, bool) {
+	for size := 1; size <= 4 && size < len(words); size++ {
+		start := len(words) - size
+		tail := make([]string, size)
+		for i, word := range words[start:] {
+			tail[i] = strings.Trim(word, "()")
+		}
+		if !startsWithNumber(tail) {
+			continue
+		}
+		phrase, ok := readQuantity(tail)
+		if !ok || phrase.words != size || (phrase.unit == "" && !phrase.marked) {
+			continue
+		}
+		description := strings.Join(fields[:start], " ")
+		return Item{
+			Quantity:    units.Round(phrase.quantity),
+			Unit:        phrase.unit,
+			Description: strings.TrimRight(description, " -,:("),
+			HasQuantity: true,
+		}, true
+	}
+	return Item{}, false
+}
+
+type quantityPhrase struct {
+	quantity float64
+	unit     string
+	// Number of words used by the phrase.
+	words int
+	// Whether the quantity was written with an "x" marker, e.g. "3x" or "x 3".
+	marked bool
+}
+
+// readQuantity reads "<number> [x|dozen] [unit]" from the start of words.
+func readQuantity(words []string) (quantityPhrase, bool) {
+	phrase := quantityPhrase{}
+	i := 0
+	// Articles and spelled fractions only count as a quantity before a unit or
+	// a multiplier, so that "a lot of apples" or "half and half" are kept as typed.
+	needsUnit := false
+	first := words[0]
+	if value, ok := parseNumber(first); ok {
+		phrase.quantity = value
+		i = 1
+		// Mixed numbers such as "1 1/2".
+		if i < len(words) && value == math.Trunc(value) && fractionRegex.MatchString(words[i]) {
+			if fraction, ok := parseNumber(words[i]); ok && fraction < 1 {
+				phrase.quantity += fraction
+				i++
+			}
+		}
+	} else if value, ok := numberWords[first]; ok {
+		phrase.quantity = value
+		needsUnit = value < 1
+		i = 1
+	} else if articleWords[first] {
+		phrase.quantity = 1
+		needsUnit = true
+		i = 1
+	} else if multiplier, ok := multiplierWords[first]; ok {
+		return readUnit(words, quantityPhrase{quantity: multiplier, words: 1})
+	} else if (first == "x" || first == "×") && len(words) > 1 {
+		value, ok := parseNumber(words[1])
+		if !ok {
+			return phrase, false
+		}
+		return readUnit(words, quantityPhrase{quantity: value, words: 2, marked: true})
+	} else if value, suffix, ok := splitNumberSuffix(first); ok {
+		phrase.quantity = value
+		phrase.words = 1
+		if suffix == "x" {
+			phrase.marked = true
+			return phrase, value > 0
+		}
+		unit, ok := units.Lookup(suffix)
+		if !ok {
+			return phrase, false
+		}
+		phrase.unit = unit
+		return phrase, value > 0
+	} else if strings.HasPrefix(first, "x") || strings.HasPrefix(first, "×") {
+		_, size := utf8.DecodeRuneInString(first)
+		value, ok := parseNumber(first[size:])
+		if !ok {
+			return phrase, false
+		}
+		return readUnit(words, quantityPhrase{quantity: value, words: 1, marked: true})
+	} else {
+		return phrase, false
+	}
+
+	// "half a dozen"
+	if i < len(words) && articleWords[words[i]] && phrase.quantity < 1 {
+		i++
+	}
+	if i < len(words) && (words[i] == "x" || words[i] == "×") {
+		phrase.marked = true
+		i++
+	} else if i < len(words) {
+		if multiplier, ok := multiplierWords[words[i]]; ok {
+			phrase.quantity *= multiplier
+			needsUnit = false
+			i++
+		}
+	}
+	phrase.words = i
+	phrase, ok := readUnit(words, phrase)
+	if needsUnit && !phrase.marked && phrase.unit == "" {
+		return phrase, false
+	}
+	return phrase, ok
+}
+
+func readUnit(words []string, phrase quantityPhrase) (quantityPhrase, bool) {
+	if phrase.words < len(words) {
+		if unit, ok := units.Lookup(words[phrase.words]); ok {
+			phrase.unit = unit
+			phrase.words++
+		}
+	}
+	return phrase, phrase.quantity > 0
+}
+
+func startsWithNumber(words []string) bool {
+	first := words[0]
+	if _, ok := parseNumber(first); ok {
+		return true
+	}
+	if _, _, ok := splitNumberSuffix(first); ok {
+		return true
+	}
+	return first == "x" || first == "×" || ((strings.HasPrefix(first, "x") || strings.HasPrefix(first, "×")) && len(first) > 1)
+}
+
+func splitNumberSuffix(word string) (float64, string, bool) {
+	match := numberSuffixRegex.FindStringSubmatch(word)
+	if match == nil {
+		return 0, "", false
+	}
+	value, ok := parseNumber(match[1])
+	if !ok {
+		return 0, "", false
+	}
+	return value, match[2], true
+}
+
+// parseNumber accepts "2", "2.5", "2,5", "1/2", "½" and "1½".
+func parseNumber(word string) (float64, bool) {
+	if word == "" {
+		return 0, false
+	}
+	last, size := utf8.DecodeLastRuneInString(word)
+	if fraction, ok := unicodeFractions[last]; ok {
+		whole := word[:len(word)-size]
+		if whole == "" {
+			return fraction, true
+		}
+		value, err := strconv.Atoi(whole)
+		if err != nil {
+			return 0, false
+		}
+		return float64(value) + fraction, true
+	}
+	if match := fractionRegex.FindStringSubmatch(word); match != nil {
+		numerator, _ := strconv.Atoi(match[1])
+		denominator, _ := strconv.Atoi(match[2])
+		if denominator == 0 {
+			return 0, false
+		}
+		return float64(numerator) / float64(denominator), true
+	}
+	if !decimalRegex.MatchString(word) {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(strings.Replace(word, ",", ".", 1), 64)
+	if err != nil {
+		return 0, false
+	}
+	return value, true
+}
@@ -0,0 +1,101 @@
+package quickadd
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want Item
+	}{
+		// English
+		{"number and unit", "2 kg tomatoes", Item{Quantity: 2, Unit: "kg", Description: "tomatoes", HasQuantity: true}},
+		{"unit glued to the number", "500g flour", Item{Quantity: 500, Unit: "g", Description: "flour", HasQuantity: true}},
+		{"decimal comma", "1,5l milk", Item{Quantity: 1.5, Unit: "l", Description: "milk", HasQuantity: true}},
+		{"decimal dot", "0.5 lb butter", Item{Quantity: 0.5, Unit: "lb", Description: "butter", HasQuantity: true}},
+		{"x marker after", "3x milk", Item{Quantity: 3, Description: "milk", HasQuantity: true}},
+		{"x marker before", "x3 milk", Item{Quantity: 3, Description: "milk", HasQuantity: true}},
+		{"fraction", "1/2 kg cheese", Item{Quantity: 0.5, Unit: "kg", Description: "cheese", HasQuantity: true}},
+		{"mixed number", "1 1/2 cups sugar", Item{Quantity: 1.5, Unit: "cup", Description: "sugar", HasQuantity: true}},
+		{"unicode fraction", "½ lb ham", Item{Quantity: 0.5, Unit: "lb", Description: "ham", HasQuantity: true}},
+		{"unicode mixed number", "1½ l water", Item{Quantity: 1.5, Unit: "l", Description: "water", HasQuantity: true}},
+		{"number word", "two cans of beans", Item{Quantity: 2, Unit: "can", Description: "beans", HasQuantity: true}},
+		{"article", "a bag of rice", Item{Quantity: 1, Unit: "bag", Description: "rice", HasQuantity: true}},
+		{"dozen", "2 dozen eggs", Item{Quantity: 24, Description: "eggs", HasQuantity: true}},
+		{"half dozen", "half dozen eggs", Item{Quantity: 6, Description: "eggs", HasQuantity: true}},
+		{"half a dozen", "half a dozen eggs", Item{Quantity: 6, Description: "eggs", HasQuantity: true}},
+		{"unit alias", "2 kilos of potatoes", Item{Quantity: 2, Unit: "kg", Description: "potatoes", HasQuantity: true}},
+		{"plural alias", "3 bottles wine", Item{Quantity: 3, Unit: "bottle", Description: "wine", HasQuantity: true}},
+		{"uppercase unit", "2 KG Tomatoes", Item{Quantity: 2, Unit: "kg", Description: "Tomatoes", HasQuantity: true}},
+		{"trailing quantity", "tomatoes 2 kg", Item{Quantity: 2, Unit: "kg", Description: "tomatoes", HasQuantity: true}},
+		{"trailing x marker", "milk x3", Item{Quantity: 3, Description: "milk", HasQuantity: true}},
+		{"trailing in parentheses", "eggs (12 units)", Item{Quantity: 12, Unit: "unit", Description: "eggs", HasQuantity: true}},
+		{"trailing after a dash", "Rice - 5 kg", Item{Quantity: 5, Unit: "kg", Description: "Rice", HasQuantity: true}},
+
+		// Portuguese
+		{"pt unit alias", "2 quilos de tomate", Item{Quantity: 2, Unit: "kg", Description: "tomate", HasQuantity: true}},
+		{"pt grams", "200 gramas de queijo", Item{Quantity: 200, Unit: "g", Description: "queijo", HasQuantity: true}},
+		{"pt decimal comma", "1,5 kg de carne", Item{Quantity: 1.5, Unit: "kg", Description: "carne", HasQuantity: true}},
+		{"pt liters", "3 litros de leite", Item{Quantity: 3, Unit: "l", Description: "leite", HasQuantity: true}},
+		{"pt number word", "duas latas de milho", Item{Quantity: 2, Unit: "can", Description: "milho", HasQuantity: true}},
+		{"pt number word with accent", "três pacotes de macarrão", Item{Quantity: 3, Unit: "pack", Description: "macarrão", HasQuantity: true}},
+		{"pt one", "um maço de salsa", Item{Quantity: 1, Unit: "bunch", Description: "salsa", HasQuantity: true}},
+		{"pt bottles", "cinco garrafas de água", Item{Quantity: 5, Unit: "bottle", Description: "água", HasQuantity: true}},
+		{"pt half dozen", "meia dúzia de ovos", Item{Quantity: 6, Description: "ovos", HasQuantity: true}},
+		{"pt dozen without accent", "1 duzia de ovos", Item{Quantity: 12, Description: "ovos", HasQuantity: true}},
+		{"pt trailing quantity", "leite 2 litros", Item{Quantity: 2, Unit: "l", Description: "leite", HasQuantity: true}},
+		{"pt x marker", "2x pão", Item{Quantity: 2, Description: "pão", HasQuantity: true}},
+
+		// No quantity or malformed
+		{"empty", "", Item{Quantity: 1}},
+		{"blank", "   ", Item{Quantity: 1}},
+		{"plain description", "tomatoes", Item{Quantity: 1, Description: "tomatoes"}},
+		{"extra spaces", "  green   apples ", Item{Quantity: 1, Description: "green apples"}},
+		{"only a number", "2", Item{Quantity: 1, Description: "2"}},
+		{"only a quantity", "2 kg", Item{Quantity: 1, Description: "2 kg"}},
+		{"name ending in a number", "iphone 15", Item{Quantity: 1, Description: "iphone 15"}},
+		{"zero quantity", "0 kg rice", Item{Quantity: 1, Description: "0 kg rice"}},
+		{"zero denominator", "1/0 kg rice", Item{Quantity: 1, Description: "1/0 kg rice"}},
+		{"x without a number", "x milk", Item{Quantity: 1, Description: "x milk"}},
+		{"number glued to a word", "7up", Item{Quantity: 1, Description: "7up"}},
+		{"article without a unit", "a lot of apples", Item{Quantity: 1, Description: "a lot of apples"}},
+		{"article before the name", "an apple", Item{Quantity: 1, Description: "an apple"}},
+		{"fraction word without a unit", "half and half", Item{Quantity: 1, Description: "half and half"}},
+		{"article and dozen", "a dozen eggs", Item{Quantity: 12, Description: "eggs", HasQuantity: true}},
+		{"pt fraction word without a unit", "meia calça", Item{Quantity: 1, Description: "meia calça"}},
+		{"malformed decimal", "1,5,2 kg rice", Item{Quantity: 1, Description: "1,5,2 kg rice"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Parse(tt.text); got != tt.want {
+				t.Errorf("Parse(%q) = %+v, want %+v", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseNumber(t *testing.T) {
+	tests := []struct {
+		word   string
+		want   float64
+		wantOk bool
+	}{
+		{"2", 2, true},
+		{"2.5", 2.5, true},
+		{"2,5", 2.5, true},
+		{"3/4", 0.75, true},
+		{"¼", 0.25, true},
+		{"2¾", 2.75, true},
+		{"", 0, false},
+		{"1/0", 0, false},
+		{"2.5.1", 0, false},
+		{"a½", 0, false},
+		{"two", 0, false},
+	}
+	for _, tt := range tests {
+		got, ok := parseNumber(tt.word)
+		if got != tt.want || ok != tt.wantOk {
+			t.Errorf("parseNumber(%q) = %v, %v, want %v, %v", tt.word, got, ok, tt.want, tt.wantOk)
+		}
+	}
+}
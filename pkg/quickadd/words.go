@@ -0,0 +1,26 @@
+package quickadd
+
+// Words multiplying the quantity before them, e.g. "2 dozen eggs" or "meia dúzia de ovos".
+var multiplierWords = map[string]float64{
+	"dozen": 12, "dozens": 12, "dúzia": 12, "dúzias": 12, "duzia": 12, "duzias": 12,
+}
+
+var numberWords = map[string]float64{
+	"one": 1, "two": 2, "three": 3, "four": 4, "five": 5, "six": 6, "seven": 7, "eight": 8,
+	"nine": 9, "ten": 10, "eleven": 11, "twelve": 12, "fifteen": 15, "twenty": 20,
+	"um": 1, "uma": 1, "dois": 2, "duas": 2, "três": 3, "tres": 3, "quatro": 4, "cinco": 5,
+	"seis": 6, "sete": 7, "oito": 8, "nove": 9, "dez": 10, "onze": 11, "doze": 12, "quinze": 15, "vinte": 20,
+	"half": 0.5, "meio": 0.5, "meia": 0.5, "quarter": 0.25,
+}
+
+// Articles that count as a single unit ("a bag of rice", "an apple").
+var articleWords = map[string]bool{"a": true, "an": true}
+
+// Words between the quantity and the description that are dropped ("2 kg of rice", "1 pacote de arroz").
+var connectorWords = map[string]bool{
+	"of": true, "de": true, "do": true, "da": true, "dos": true, "das": true,
+}
+
+var unicodeFractions = map[rune]float64{
+	'½': 0.5, '¼': 0.25, '¾': 0.75, '⅓': 1.0 / 3, '⅔': 2.0 / 3, '⅛': 0.125,
+}
@@ -2,16 +2,27 @@ package realtime
 
 // Must match actionType in html
 const (
-	ACTION_NOOP         = iota
-	ACTION_FOCUS_ITEM   = iota
-	ACTION_UNFOCUS_ITEM = iota
-	ACTION_UPDATE_COLOR = iota
-	ACTION_ADD_GROUP    = iota
-	ACTION_EDIT_GROUP   = iota
-	ACTION_ADD_ITEM     = iota
-	ACTION_DELETE_GROUP = iota
-	ACTION_DELETE_ITEM  = iota
-	ACTION_EDIT_ITEM    = iota
+	ACTION_NOOP            = iota
+	ACTION_FOCUS_ITEM      = iota
+	ACTION_UNFOCUS_ITEM    = iota
+	ACTION_UPDATE_COLOR    = iota
+	ACTION_ADD_GROUP       = iota
+	ACTION_EDIT_GROUP      = iota
+	ACTION_ADD_ITEM        = iota
+	ACTION_DELETE_GROUP    = iota
+	ACTION_DELETE_ITEM     = iota
+	ACTION_EDIT_ITEM       = iota
+	ACTION_BULK_ADD        = iota
+	ACTION_UNDO            = iota
+	ACTION_CHECK_ITEM      = iota
+	ACTION_CHECK_ALL       = iota
+	ACTION_UNCHECK_ALL     = iota
+	ACTION_CLEAR_CHECKED   = iota
+	ACTION_DUPLICATE_GROUP = iota
+	ACTION_START_TRIP      = iota
+	ACTION_END_TRIP        = iota
+	ACTION_ASSIGN_ITEM     = iota
+	ACTION_SUGGEST         = iota
 )
 
 type Action struct {
@@ -49,6 +60,35 @@ type AddItemAction struct {
 	GroupIndex int64 `json:"groupIndex"`
 }
 
+type BulkAddItemsArgs struct {
+	GroupIndex int64  `json:"groupIndex"`
+	Text       string `json:"text"`
+}
+
+type CheckItemArgs struct {
+	GroupIndex int64 `json:"groupIndex"`
+	ItemIndex  int64 `json:"itemIndex"`
+}
+
+// AssigneeId is empty to unassign the item.
+type AssignItemArgs struct {
+	GroupIndex int64  `json:"groupIndex"`
+	ItemIndex  int64  `json:"itemIndex"`
+	AssigneeId string `json:"assigneeId"`
+}
+
+// Description is what is being typed in the item, to be completed.
+type SuggestArgs struct {
+	GroupIndex  int64  `json:"groupIndex"`
+	ItemIndex   int64  `json:"itemIndex"`
+	Description string `json:"description"`
+}
+
+// Operates on a single group, or on the whole list when GroupIndex is missing.
+type GroupBulkArgs struct {
+	GroupIndex *int64 `json:"groupIndex"`
+}
+
 type EditItemAction struct {
 	GroupIndex int64 `json:"groupIndex"`
 }
@@ -62,5 +102,9 @@ type EditItemArgs struct {
 	ItemIndex   int64  `json:"itemIndex"`
 	Description string `json:"description"`
 	Quantity    string `json:"quantity"`
+	Unit        string `json:"unit"`
+	Price       string `json:"price"`
+	Currency    string `json:"currency"`
+	Note        string `json:"note"`
 	Field       string `json:"field"`
 }
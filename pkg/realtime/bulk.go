@@ -0,0 +1,30 @@
+package realtime
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Maximum number of items a single paste can add.
+const maxBulkItems = 200
+
+// Matches list markers people paste along with items, e.g. "- ", "* ", "1. ", "[ ] ".
+var listMarkerRegex = regexp.MustCompile(`^(?:[-*+•]|\d+[.)]|\[[ xX]?\])\s+`)
+
+// SplitItemLines turns pasted text into item descriptions, one per non-empty line.
+func SplitItemLines(text string) []string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+	result := make([]string, 0, len(lines))
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		line = strings.TrimSpace(listMarkerRegex.ReplaceAllString(line, ""))
+		if line == "" {
+			continue
+		}
+		result = append(result, line)
+		if len(result) == maxBulkItems {
+			break
+		}
+	}
+	return result
+}
@@ -1,31 +1,33 @@
 package realtime
 
+import "sync"
+
 type Generator struct {
 	InitialValue int64
 	CurrentValue int64
-	NextBuffer   chan int64
+	mu           sync.Mutex
 }
 
 func NewGenerator(initialValue int64) *Generator {
-	g := &Generator{
+	return &Generator{
 		InitialValue: initialValue,
 		CurrentValue: initialValue,
-		NextBuffer:   make(chan int64, 30),
 	}
-	g.start()
-	return g
 }
 
 func (g *Generator) Next() int64 {
-	return <-g.NextBuffer
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	val := g.CurrentValue
+	g.CurrentValue++
+	return val
 }
 
-func (g *Generator) start() {
-	go func() {
-		val := g.InitialValue
-		for {
-			g.NextBuffer <- val
-			val = val + 1
-		}
-	}()
+// SkipTo makes sure the next values are at least value.
+func (g *Generator) SkipTo(value int64) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if value > g.CurrentValue {
+		g.CurrentValue = value
+	}
 }
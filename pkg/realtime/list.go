@@ -3,13 +3,25 @@ package realtime
 import (
 	"bytes"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"log"
+	"maps"
+	"slices"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"vilmasoftware.com/colablists/pkg/attachment"
+	"vilmasoftware.com/colablists/pkg/config"
+	"vilmasoftware.com/colablists/pkg/infra"
 	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/pantry"
+	"vilmasoftware.com/colablists/pkg/purchase"
+	"vilmasoftware.com/colablists/pkg/share"
+	"vilmasoftware.com/colablists/pkg/trip"
 	"vilmasoftware.com/colablists/pkg/user"
 	"vilmasoftware.com/colablists/pkg/views"
 )
@@ -18,6 +30,16 @@ type connection struct {
 	ListId int64
 	User   *user.User
 	Conn   *websocket.Conn
+	// Link the guest follows the list through, nil for its members
+	Share *share.Share
+}
+
+// buyer is who the purchases of the connection are recorded for, the creator of the link for guests.
+func (c *connection) buyer() *user.User {
+	if c.Share != nil {
+		return &user.User{Id: c.Share.CreatorId}
+	}
+	return c.User
 }
 
 func (c *connection) String() string {
@@ -25,11 +47,23 @@ func (c *connection) String() string {
 }
 
 type LiveEditor struct {
-	listsById      map[int64]*ListState
-	listRepository list.ListsRepository
+	// mu guards the lists being edited, which the connections, the handlers and the schedulers
+	// change from their own goroutines. Unexported methods expect it held.
+	mu                  sync.Mutex
+	listsById           map[int64]*ListState
+	listRepository      list.ListsRepository
+	usersRepository     user.UsersRepository
+	tripsRepository     trip.TripsRepository
+	purchasesRepository purchase.PurchasesRepository
+	pantry              *pantry.Service
+	// Updating the pantry may add to the lists being edited, so the updates wait
+	// for the action that checked the items to release the lock
+	pantryUpdates []func()
 }
 
 func (l *LiveEditor) Info() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	println("Live editor info")
 	for k, v := range l.listsById {
 		println("List ", k, " has ", len(v.Ui.ColaboratorsOnline), " colaborators")
@@ -37,27 +71,52 @@ func (l *LiveEditor) Info() {
 	}
 }
 
+// Lists being shopped for stay in memory while the shopper may still be in the store,
+// until their trip is idle for this long and is ended.
+const tripIdleTimeout = 2 * time.Hour
+
 func (l *LiveEditor) HandleTimeouts() {
 	ticker := time.NewTicker(5 * time.Minute)
 	for {
 		<-ticker.C
 		println("Starting timeout handler")
-		for k, v := range l.listsById {
-			if time.Since(v.Ui.LastUsed) > 5*time.Minute {
-				println("removing list ", k)
-				for _, conn := range v.connections {
-					l.removeConnection(conn.Conn)
+		l.evictIdle()
+	}
+}
+
+// evictIdle drops the lists nobody used for a while. A list with an active trip is kept
+// until tripIdleTimeout, then its trip is ended and the summary saved.
+func (l *LiveEditor) evictIdle() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for k, v := range l.listsById {
+		idle := time.Since(v.Ui.LastUsed)
+		if v.Ui.Trip != nil && idle <= tripIdleTimeout {
+			continue
+		}
+		if idle > 5*time.Minute {
+			if summary := v.EndTrip(); summary != nil {
+				if _, err := l.tripsRepository.Save(summary); err != nil {
+					log.Println("Error saving summary of idle trip", err)
 				}
-				delete(l.listsById, k)
 			}
+			println("removing list ", k)
+			for _, conn := range v.connections {
+				l.removeConnection(conn.Conn)
+			}
+			delete(l.listsById, k)
 		}
 	}
 }
 
-func NewLiveEditor(repository list.ListsRepository) *LiveEditor {
+func NewLiveEditor(repository list.ListsRepository, usersRepository user.UsersRepository, tripsRepository trip.TripsRepository, purchasesRepository purchase.PurchasesRepository, pantry *pantry.Service) *LiveEditor {
 	editor := &LiveEditor{
-		listRepository: repository,
-		listsById:      make(map[int64]*ListState),
+		listRepository:      repository,
+		usersRepository:     usersRepository,
+		tripsRepository:     tripsRepository,
+		purchasesRepository: purchasesRepository,
+		pantry:              pantry,
+		listsById:           make(map[int64]*ListState),
 	}
 	go editor.HandleTimeouts()
 	return editor
@@ -71,6 +130,13 @@ func (l *LiveEditor) GetConnectionsOfList(listId int64) []*connection {
 	return conns.connections
 }
 
+// disconnect removes the connection, once it was closed.
+func (l *LiveEditor) disconnect(conn *websocket.Conn) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.removeConnection(conn)
+}
+
 func (l *LiveEditor) removeConnection(conn *websocket.Conn) {
 	for k, v := range l.listsById {
 		connections := make([]*connection, 0)
@@ -84,27 +150,30 @@ func (l *LiveEditor) removeConnection(conn *websocket.Conn) {
 }
 
 func (l *LiveEditor) HandleWebsocketConn(conn *connection) {
+	// Others write to the connection when broadcasting, holding the lock
+	l.mu.Lock()
 	conn.Conn.WriteMessage(websocket.TextMessage, []byte("Hello"))
+	l.mu.Unlock()
 	for {
 		messageType, p, err := conn.Conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				l.removeConnection(conn.Conn)
+				l.disconnect(conn.Conn)
 				log.Printf("unexcepted Close Error: %v\n", err)
 				return
 			} else if websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
-				l.removeConnection(conn.Conn)
+				l.disconnect(conn.Conn)
 				log.Printf("close Error: %v\n", err)
 				return
 			} else {
-				l.removeConnection(conn.Conn)
+				l.disconnect(conn.Conn)
 				log.Printf("Unexpected error reading websocket message %v\n", err)
 				return
 			}
 		}
 		switch messageType {
 		case websocket.CloseMessage:
-			l.removeConnection(conn.Conn)
+			l.disconnect(conn.Conn)
 			continue
 		case websocket.PingMessage:
 			continue
@@ -123,78 +192,171 @@ func (l *LiveEditor) HandleWebsocketConn(conn *connection) {
 				log.Println("ActionType is nil")
 				continue
 			}
-			listState := l.GetCurrentListState(conn.ListId)
-			if listState != nil {
-				listState.Ui.LastUsed = time.Now()
-			}
-			switch *action.Type {
-			case ACTION_FOCUS_ITEM:
-				var focusItemAction FocusItemAction
-				if err := json.Unmarshal(p, &focusItemAction); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleFocusItem(&focusItemAction, conn)
-			case ACTION_UNFOCUS_ITEM:
-				var unfocusItemAction UnfocusItemAction
-				if err := json.Unmarshal(p, &unfocusItemAction); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleUnfocusItem(&unfocusItemAction, conn)
-			case ACTION_UPDATE_COLOR:
-				var updateColorAction UpdateColorAction
-				if err := json.Unmarshal(p, &updateColorAction); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleUpdateColor(&updateColorAction, conn)
-			case ACTION_ADD_GROUP:
-				l.HandleAddGroup("New Group", conn)
-			case ACTION_ADD_ITEM:
-				var addItemAction AddItemAction
-				if err := json.Unmarshal(p, &addItemAction); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleAddItem(&addItemAction, conn)
-			case ACTION_EDIT_GROUP:
-				var editGroupAction EditGroupAction
-				if err := json.Unmarshal(p, &editGroupAction); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleEditGroup(&editGroupAction, conn)
-			case ACTION_DELETE_GROUP:
-				var deleteGroupAction DeleteGroupArgs
-				if err := json.Unmarshal(p, &deleteGroupAction); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleDeleteGroup(&deleteGroupAction, conn)
-			case ACTION_DELETE_ITEM:
-				var deleteItemArgs DeleteItemArgs
-				if err := json.Unmarshal(p, &deleteItemArgs); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleDeleteItem(&deleteItemArgs, conn)
-			case ACTION_EDIT_ITEM:
-				var editItemArgs EditItemArgs
-				if err := json.Unmarshal(p, &editItemArgs); err != nil {
-					log.Println("Error unmarshalling action", err)
-					continue
-				}
-				l.HandleEditItem(&editItemArgs, conn)
+			if *action.Type == ACTION_SUGGEST {
+				l.HandleSuggest(conn, p)
+				continue
 			}
+			l.handleAction(conn, *action.Type, p)
+			l.updatePantry()
+		}
+	}
+}
 
+// handleAction takes the action sent through the connection, holding the lock.
+func (l *LiveEditor) handleAction(conn *connection, actionType int, p []byte) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(conn.ListId)
+	if listState != nil {
+		listState.Ui.LastUsed = time.Now()
+	}
+	if !l.allows(conn, actionType, p) {
+		log.Printf("User %d cannot take action %d on list %d\n", conn.User.Id, actionType, conn.ListId)
+		return
+	}
+	switch actionType {
+	case ACTION_FOCUS_ITEM:
+		var focusItemAction FocusItemAction
+		if err := json.Unmarshal(p, &focusItemAction); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleFocusItem(&focusItemAction, conn)
+	case ACTION_UNFOCUS_ITEM:
+		var unfocusItemAction UnfocusItemAction
+		if err := json.Unmarshal(p, &unfocusItemAction); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleUnfocusItem(&unfocusItemAction, conn)
+	case ACTION_UPDATE_COLOR:
+		var updateColorAction UpdateColorAction
+		if err := json.Unmarshal(p, &updateColorAction); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleUpdateColor(&updateColorAction, conn)
+	case ACTION_ADD_GROUP:
+		l.HandleAddGroup("New Group", conn)
+	case ACTION_ADD_ITEM:
+		var addItemAction AddItemAction
+		if err := json.Unmarshal(p, &addItemAction); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleAddItem(&addItemAction, conn)
+	case ACTION_EDIT_GROUP:
+		var editGroupAction EditGroupAction
+		if err := json.Unmarshal(p, &editGroupAction); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleEditGroup(&editGroupAction, conn)
+	case ACTION_DELETE_GROUP:
+		var deleteGroupAction DeleteGroupArgs
+		if err := json.Unmarshal(p, &deleteGroupAction); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
 		}
+		l.HandleDeleteGroup(&deleteGroupAction, conn)
+	case ACTION_DELETE_ITEM:
+		var deleteItemArgs DeleteItemArgs
+		if err := json.Unmarshal(p, &deleteItemArgs); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleDeleteItem(&deleteItemArgs, conn)
+	case ACTION_EDIT_ITEM:
+		var editItemArgs EditItemArgs
+		if err := json.Unmarshal(p, &editItemArgs); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleEditItem(&editItemArgs, conn)
+	case ACTION_BULK_ADD:
+		var bulkAddItemsArgs BulkAddItemsArgs
+		if err := json.Unmarshal(p, &bulkAddItemsArgs); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleBulkAddItems(&bulkAddItemsArgs, conn)
+	case ACTION_UNDO:
+		l.HandleUndo(conn)
+	case ACTION_CHECK_ITEM:
+		var checkItemArgs CheckItemArgs
+		if err := json.Unmarshal(p, &checkItemArgs); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleCheckItem(&checkItemArgs, conn)
+	case ACTION_CHECK_ALL, ACTION_UNCHECK_ALL, ACTION_CLEAR_CHECKED, ACTION_DUPLICATE_GROUP:
+		var groupBulkArgs GroupBulkArgs
+		if err := json.Unmarshal(p, &groupBulkArgs); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleGroupBulk(actionType, &groupBulkArgs, conn)
+	case ACTION_START_TRIP:
+		l.HandleStartTrip(conn)
+	case ACTION_END_TRIP:
+		l.HandleEndTrip(conn)
+	case ACTION_ASSIGN_ITEM:
+		var assignItemArgs AssignItemArgs
+		if err := json.Unmarshal(p, &assignItemArgs); err != nil {
+			log.Println("Error unmarshalling action", err)
+			return
+		}
+		l.HandleAssignItem(&assignItemArgs, conn)
+	}
+}
+
+// updatePantry runs the pantry updates queued by the actions taken so far.
+func (l *LiveEditor) updatePantry() {
+	l.mu.Lock()
+	updates := l.pantryUpdates
+	l.pantryUpdates = nil
+	l.mu.Unlock()
+	for _, update := range updates {
+		update()
 	}
 }
 
 func (l *LiveEditor) SetupList(listId int64, user *user.User, conn *websocket.Conn) {
+	l.setup(&connection{ListId: listId, User: user, Conn: conn})
+}
+
+// SetupShare has a guest follow the list of the link on the connection, unless the list is in the trash.
+func (l *LiveEditor) SetupShare(s *share.Share, conn *websocket.Conn) error {
+	// The trash is not shown to the editors, so only the saved list tells it
+	saved, err := l.listRepository.Get(s.ListId)
+	if err != nil {
+		return err
+	}
+	if saved.DeletedAt != nil {
+		return ErrListTrashed
+	}
+	l.setup(&connection{ListId: s.ListId, User: s.Guest(), Conn: conn, Share: s})
+	return nil
+}
+
+var ErrListTrashed = errors.New("the list is in the trash")
+
+// Unshare disconnects the guests following the list through a revoked link.
+func (l *LiveEditor) Unshare(listId int64, shareId int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, conn := range l.GetConnectionsOfList(listId) {
+		if conn.Share != nil && conn.Share.Id == shareId {
+			conn.Conn.Close()
+		}
+	}
+}
+
+func (l *LiveEditor) setup(conn2 *connection) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listId, user := conn2.ListId, conn2.User
 	listUi, ok := l.listsById[listId]
-	conn2 := &connection{ListId: listId, User: user, Conn: conn}
 	if !ok {
 		list, err := l.listRepository.Get(listId)
 		assertNotNil(err)
@@ -202,16 +364,7 @@ func (l *LiveEditor) SetupList(listId int64, user *user.User, conn *websocket.Co
 		listUi = l.listsById[listId]
 	} else {
 		listUi.connections = append(listUi.connections, conn2)
-		found := false
-		for _, userUi := range listUi.Ui.ColaboratorsOnline {
-			if userUi.Id == user.Id {
-				found = true
-				break
-			}
-		}
-		if !found {
-			listUi.Ui.ColaboratorsOnline = append(listUi.Ui.ColaboratorsOnline, &views.UserUi{User: user, Color: "#18d825"})
-		}
+		listUi.AddColaboratorOnline(user)
 		l.listsById[listId] = listUi
 	}
 	s := ""
@@ -281,7 +434,21 @@ func (l *LiveEditor) HandleUpdateColor(action *UpdateColorAction, conn *connecti
 	if !ok {
 		return
 	}
-	l.GetColaboratorOnline(conn.ListId, action.UserId).Color = action.Color
+	colaborator := l.GetColaboratorOnline(conn.ListId, conn.User.Id)
+	if colaborator == nil {
+		return
+	}
+	// Users can only pick their own color. Anything else is answered with the
+	// current colaborators list so the color inputs go back to their real values.
+	color, valid := user.NormalizeColor(action.Color)
+	if action.UserId == conn.User.Id && valid {
+		if err := l.usersRepository.UpdateColor(conn.User.Id, color); err != nil {
+			log.Println("Error saving user color", err)
+		} else {
+			conn.User.Color = color
+			colaborator.Color = color
+		}
+	}
 
 	s := ""
 	buf := bytes.NewBufferString(s)
@@ -293,7 +460,7 @@ func (l *LiveEditor) HandleUpdateColor(action *UpdateColorAction, conn *connecti
 }
 
 func (l *LiveEditor) HandleAddGroup(groupText string, conn *connection) {
-	listState := l.GetCurrentListState(conn.ListId)
+	listState := l.state(conn.ListId)
 	if listState == nil {
 		return
 	}
@@ -304,7 +471,7 @@ func (l *LiveEditor) HandleAddGroup(groupText string, conn *connection) {
 	}
 	s := ""
 	buf := bytes.NewBufferString(s)
-	views.Templates.RenderGroup(buf, *views.NewGroupIndex(group.GroupId, group, "beforeend:#groups"))
+	views.Templates.RenderGroup(buf, groupArgs(listState, group, "beforeend:#groups"))
 	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
 	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
 		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
@@ -312,7 +479,7 @@ func (l *LiveEditor) HandleAddGroup(groupText string, conn *connection) {
 }
 
 func (l *LiveEditor) HandleEditGroup(action *EditGroupAction, conn *connection) {
-	listState := l.GetCurrentListState(conn.ListId)
+	listState := l.state(conn.ListId)
 	if listState == nil {
 		return
 	}
@@ -321,9 +488,7 @@ func (l *LiveEditor) HandleEditGroup(action *EditGroupAction, conn *connection)
 	l.SetDirty(conn.ListId)
 	s := ""
 	buf := bytes.NewBufferString(s)
-	gi := *views.NewGroupIndex(group.GroupId, group, "outerHTML:")
-	gi.HxSwapOob = "outerHTML:#" + gi.Id
-	views.Templates.RenderGroup(buf, gi)
+	renderGroupReplace(buf, listState, group)
 	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(editList.List, conn.User), IsDirty: listState.Dirty})
 
 	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
@@ -332,7 +497,7 @@ func (l *LiveEditor) HandleEditGroup(action *EditGroupAction, conn *connection)
 }
 
 func (l *LiveEditor) HandleAddItem(args *AddItemAction, conn *connection) {
-	listState := l.GetCurrentListState(conn.ListId)
+	listState := l.state(conn.ListId)
 	if listState == nil {
 		return
 	}
@@ -346,15 +511,360 @@ func (l *LiveEditor) HandleAddItem(args *AddItemAction, conn *connection) {
 	buf := bytes.NewBufferString(s)
 	color := l.GetColaboratorOnline(conn.ListId, conn.User.Id).Color
 	groupIdStr := strconv.FormatInt(int64(args.GroupIndex), 10)
-	views.Templates.RenderItem(buf, *views.NewIndexedItem(item.GroupId, item.Id, item, color, nil, "beforeend:#items-"+groupIdStr))
+	views.Templates.RenderItem(buf, itemArgs(listState, item.GroupId, item.Id, item, color, "beforeend:#items-"+groupIdStr))
+	renderGroupTotal(buf, listState, listState.FindGroupById(args.GroupIndex))
+	renderTripIfActive(buf, listState)
 	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(editList.List, conn.User), IsDirty: true})
 	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
 		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
 	}
 }
 
+func (l *LiveEditor) HandleBulkAddItems(args *BulkAddItemsArgs, conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil {
+		return
+	}
+	items := listState.AddItems(conn.User.Id, args.GroupIndex, SplitItemLines(args.Text))
+	if len(items) == 0 {
+		return
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	color := l.GetColaboratorOnline(conn.ListId, conn.User.Id).Color
+	groupIdStr := strconv.FormatInt(args.GroupIndex, 10)
+	for _, item := range items {
+		views.Templates.RenderItem(buf, itemArgs(listState, item.GroupId, item.Id, item, color, "beforeend:#items-"+groupIdStr))
+	}
+	renderGroupTotal(buf, listState, listState.FindGroupById(args.GroupIndex))
+	renderTripIfActive(buf, listState)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
+	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+func (l *LiveEditor) HandleUndo(conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil {
+		return
+	}
+	groupIds, ok := listState.Undo(conn.User.Id)
+	if !ok {
+		return
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	for _, groupId := range groupIds {
+		group := listState.FindGroupById(groupId)
+		if group == nil {
+			g := *views.NewGroupIndex(groupId, &list.Group{}, "delete")
+			g.HxSwapOob = "delete:#" + g.Id
+			views.Templates.RenderGroup(buf, g)
+			continue
+		}
+		renderGroupReplace(buf, listState, group)
+	}
+	renderTripIfActive(buf, listState)
+	renderBudget(buf, listState)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
+	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+func (l *LiveEditor) HandleCheckItem(args *CheckItemArgs, conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil {
+		return
+	}
+	item := listState.ToggleItem(args.GroupIndex, args.ItemIndex)
+	if item == nil {
+		return
+	}
+	listState.RecordPick(item, conn.User)
+	if item.Checked != 0 {
+		l.recordPurchases(listState, conn.buyer(), item)
+	} else {
+		l.undoPurchase(listState, item)
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	color := l.GetColaboratorOnline(conn.ListId, conn.User.Id).Color
+	i := itemArgs(listState, args.GroupIndex, args.ItemIndex, item, color, fmt.Sprintf("outerHTML:#desc-%d-%d", args.GroupIndex, args.ItemIndex))
+	views.Templates.RenderItem(buf, i)
+	renderGroupTotal(buf, listState, listState.FindGroupById(args.GroupIndex))
+	renderTripIfActive(buf, listState)
+	renderBudget(buf, listState)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
+	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+	// Guests can't save, so what they check is saved right away, leaving the changes of the members unsaved
+	if conn.Share != nil {
+		if err := l.listRepository.CheckItem(conn.ListId, item.Id, item.Checked); err != nil {
+			log.Println("Error saving the item checked by a guest", item.Id, err)
+		}
+	}
+}
+
+// Unchecking an item this soon after checking it takes the purchase back, as it was likely a slip.
+const undoPurchaseWindow = 10 * time.Minute
+
+// recordPurchases keeps the items the user checked in the purchase history.
+func (l *LiveEditor) recordPurchases(listState *ListState, buyer *user.User, items ...*list.Item) {
+	now := time.Now()
+	for _, item := range items {
+		p := &purchase.Purchase{
+			ListId:      listState.Ui.List.Id,
+			ItemId:      item.Id,
+			Buyer:       *buyer,
+			Description: item.Description,
+			Quantity:    item.Quantity,
+			Unit:        item.Unit,
+			UnitPrice:   item.UnitPrice,
+			Currency:    item.Currency,
+			PurchasedAt: now,
+		}
+		if p.Currency == "" {
+			p.Currency = listState.Ui.List.Currency
+		}
+		if listState.Ui.List.Community != nil {
+			p.CommunityId = &listState.Ui.List.Community.CommunityId
+		}
+		if err := l.purchasesRepository.Record(p); err != nil {
+			log.Println("Error recording purchase of item", item.Id, err)
+			continue
+		}
+		if p.CommunityId == nil {
+			continue
+		}
+		l.pantryUpdates = append(l.pantryUpdates, func() {
+			if err := l.pantry.Restock(*p.CommunityId, p.Description, p.Quantity, p.Unit); err != nil {
+				log.Println("Error restocking pantry with item", item.Id, err)
+			}
+		})
+	}
+}
+
+func (l *LiveEditor) undoPurchase(listState *ListState, item *list.Item) {
+	p, err := l.purchasesRepository.Undo(listState.Ui.List.Id, item.Id, time.Now().Add(-undoPurchaseWindow))
+	if err != nil {
+		log.Println("Error undoing purchase of item", item.Id, err)
+		return
+	}
+	if p == nil || p.CommunityId == nil {
+		return
+	}
+	l.pantryUpdates = append(l.pantryUpdates, func() {
+		if err := l.pantry.Unstock(*p.CommunityId, p.Description, p.Quantity, p.Unit); err != nil {
+			log.Println("Error taking back pantry stock of item", item.Id, err)
+		}
+	})
+}
+
+// HandleGroupBulk applies check all, uncheck all, clear checked or duplicate group
+// and broadcasts every affected group in a single message.
+func (l *LiveEditor) HandleGroupBulk(actionType int, args *GroupBulkArgs, conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil {
+		return
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	switch actionType {
+	case ACTION_CHECK_ALL, ACTION_UNCHECK_ALL, ACTION_CLEAR_CHECKED:
+		var groups []*list.Group
+		if actionType == ACTION_CLEAR_CHECKED {
+			groups = listState.ClearChecked(conn.User.Id, args.GroupIndex)
+		} else {
+			var changed []*list.Item
+			groups, changed = listState.SetChecked(conn.User.Id, args.GroupIndex, actionType == ACTION_CHECK_ALL)
+			// Only the items checked now are picked by the user, the others keep who picked them
+			for _, item := range changed {
+				listState.RecordPick(item, conn.User)
+			}
+			// Unchecking everything starts the list over, what was bought stays in the history
+			if actionType == ACTION_CHECK_ALL {
+				l.recordPurchases(listState, conn.buyer(), changed...)
+			}
+		}
+		for _, group := range groups {
+			renderGroupReplace(buf, listState, group)
+		}
+		renderTripIfActive(buf, listState)
+	case ACTION_DUPLICATE_GROUP:
+		if args.GroupIndex == nil {
+			return
+		}
+		group := listState.DuplicateGroup(conn.User.Id, *args.GroupIndex)
+		if group == nil {
+			return
+		}
+		views.Templates.RenderGroup(buf, groupArgs(listState, group, "beforeend:#groups"))
+	}
+	renderBudget(buf, listState)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
+	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+func (l *LiveEditor) HandleAssignItem(args *AssignItemArgs, conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil {
+		return
+	}
+	var assigneeId *int64
+	if args.AssigneeId != "" {
+		id, err := strconv.ParseInt(args.AssigneeId, 10, 64)
+		if err != nil {
+			return
+		}
+		assigneeId = &id
+	}
+	var previousId *int64
+	if item := listState.FindItemById(args.GroupIndex, args.ItemIndex); item != nil {
+		previousId = item.AssigneeId
+	}
+	item := listState.AssignItem(args.GroupIndex, args.ItemIndex, assigneeId)
+	if item == nil {
+		return
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	color := l.GetColaboratorOnline(conn.ListId, conn.User.Id).Color
+	i := itemArgs(listState, args.GroupIndex, args.ItemIndex, item, color, "")
+	views.Templates.RenderItemAssignee(buf, i)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
+	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+	// Only someone else that was just given the item is told
+	assignee := i.Assignee()
+	if assignee != nil && assignee.Id != conn.User.Id && (previousId == nil || *previousId != assignee.Id) {
+		go notifyAssignee(*assignee, *conn.User, listState.Ui.List, item.Description)
+	}
+}
+
+// Suggestions offered while typing an item
+const maxSuggestions = 8
+
+// HandleSuggest completes what the user is typing, answering only them. Suggestions are looked
+// up without holding the lock, which would stop the editing of every list until they are found.
+func (l *LiveEditor) HandleSuggest(conn *connection, p []byte) {
+	var args SuggestArgs
+	if err := json.Unmarshal(p, &args); err != nil {
+		log.Println("Error unmarshalling action", err)
+		return
+	}
+	l.mu.Lock()
+	allowed := l.allows(conn, ACTION_SUGGEST, p)
+	l.mu.Unlock()
+	if !allowed {
+		log.Printf("User %d cannot take action %d on list %d\n", conn.User.Id, ACTION_SUGGEST, conn.ListId)
+		return
+	}
+	suggestions, err := l.listRepository.Suggest(conn.User.Id, args.Description, maxSuggestions)
+	if err != nil {
+		log.Println("Error suggesting items", err)
+		return
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	views.Templates.RenderSuggestions(buf, suggestions)
+	// Writes to the connections take turns through the lock
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+}
+
+// notifyAssignee emails the user that was given an item to buy.
+func notifyAssignee(assignee user.User, assigner user.User, l *list.List, description string) {
+	body := fmt.Sprintf("%s asked you to buy %q in the list %q.\n\nSee the list at %s/lists/%d\n",
+		assigner.Username, description, l.Title, config.GetConfig().AppUrl, l.Id)
+	if err := infra.SendEmail([]string{assignee.Email}, "You have a new item to buy", body); err != nil {
+		log.Println("Error notifying assignee", assignee.Id, err)
+	}
+}
+
+func (l *LiveEditor) HandleStartTrip(conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil || !listState.StartTrip(conn.User) {
+		return
+	}
+	l.broadcastTrip(listState, nil)
+}
+
+func (l *LiveEditor) HandleEndTrip(conn *connection) {
+	listState := l.state(conn.ListId)
+	if listState == nil {
+		return
+	}
+	summary := listState.EndTrip()
+	if summary == nil {
+		return
+	}
+	if _, err := l.tripsRepository.Save(summary); err != nil {
+		log.Println("Error saving trip summary", err)
+	}
+	l.broadcastTrip(listState, summary)
+}
+
+func (l *LiveEditor) broadcastTrip(listState *ListState, summary *trip.Trip) {
+	s := ""
+	buf := bytes.NewBufferString(s)
+	args := views.NewTripArgs(listState.Ui)
+	args.Summary = summary
+	views.Templates.RenderTrip(buf, args)
+	for _, conn := range l.GetConnectionsOfList(listState.Ui.List.Id) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+// renderTripIfActive renders the trip progress when someone is shopping for the list.
+func renderTripIfActive(w io.Writer, listState *ListState) {
+	if listState.Ui.Trip != nil {
+		views.Templates.RenderTrip(w, views.NewTripArgs(listState.Ui))
+	}
+}
+
+// groupArgs prepares the group for rendering with the settings of its list.
+func groupArgs(listState *ListState, group *list.Group, hxSwapOob string) views.GroupArgs {
+	g := *views.NewGroupIndex(group.GroupId, group, hxSwapOob)
+	g.Currency = listState.Ui.List.Currency
+	g.Assignees = listState.Ui.List.Members()
+	return g
+}
+
+func itemArgs(listState *ListState, groupIndex, itemIndex int64, item *list.Item, color string, hxSwapOob string) views.ItemArgs {
+	i := *views.NewIndexedItem(groupIndex, itemIndex, item, color, nil, hxSwapOob)
+	i.Assignees = listState.Ui.List.Members()
+	return i
+}
+
+// renderGroupTotal renders the sum of the quantities and prices of the group.
+func renderGroupTotal(w io.Writer, listState *ListState, group *list.Group) {
+	if group == nil {
+		return
+	}
+	views.Templates.RenderGroupTotal(w, groupArgs(listState, group, ""))
+}
+
+// renderGroupReplace renders the group replacing the one with same id in the page.
+func renderGroupReplace(w io.Writer, listState *ListState, group *list.Group) {
+	gi := groupArgs(listState, group, "")
+	gi.HxSwapOob = "outerHTML:#" + gi.Id
+	views.Templates.RenderGroup(w, gi)
+}
+
+// renderBudget renders the price totals of the list, warning everyone when it goes over budget.
+func renderBudget(w io.Writer, listState *ListState) {
+	views.Templates.RenderBudget(w, listState.Ui)
+}
+
 func (l *LiveEditor) HandleDeleteGroup(args *DeleteGroupArgs, conn *connection) {
-	listState := l.GetCurrentListState(conn.ListId)
+	listState := l.state(conn.ListId)
 	if listState == nil {
 		return
 	}
@@ -364,6 +874,8 @@ func (l *LiveEditor) HandleDeleteGroup(args *DeleteGroupArgs, conn *connection)
 	g := *views.NewGroupIndex(args.GroupIndex, &list.Group{}, "delete")
 	g.HxSwapOob = "delete:#" + g.Id
 	views.Templates.RenderGroup(buf, g)
+	renderTripIfActive(buf, listState)
+	renderBudget(buf, listState)
 	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
 	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
 		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
@@ -371,7 +883,7 @@ func (l *LiveEditor) HandleDeleteGroup(args *DeleteGroupArgs, conn *connection)
 }
 
 func (l *LiveEditor) HandleDeleteItem(args *DeleteItemArgs, conn *connection) {
-	listState := l.GetCurrentListState(conn.ListId)
+	listState := l.state(conn.ListId)
 	if listState == nil {
 		return
 	}
@@ -382,6 +894,9 @@ func (l *LiveEditor) HandleDeleteItem(args *DeleteItemArgs, conn *connection) {
 	color := l.GetColaboratorOnline(conn.ListId, conn.User.Id).Color
 	i := *views.NewIndexedItem(args.GroupIndex, args.ItemIndex, &list.Item{}, color, nil, fmt.Sprintf("delete:#desc-%d-%d", args.GroupIndex, args.ItemIndex))
 	views.Templates.RenderItem(buf, i)
+	renderGroupTotal(buf, listState, listState.FindGroupById(args.GroupIndex))
+	renderTripIfActive(buf, listState)
+	renderBudget(buf, listState)
 	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(editList.List, conn.User), IsDirty: true})
 	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
 		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
@@ -389,7 +904,7 @@ func (l *LiveEditor) HandleDeleteItem(args *DeleteItemArgs, conn *connection) {
 }
 
 func (l *LiveEditor) HandleEditItem(args *EditItemArgs, conn *connection) {
-	listState := l.GetCurrentListState(conn.ListId)
+	listState := l.state(conn.ListId)
 	if listState == nil {
 		return
 	}
@@ -397,6 +912,7 @@ func (l *LiveEditor) HandleEditItem(args *EditItemArgs, conn *connection) {
 	if oldItem == nil {
 		return
 	}
+	oldQuantity, oldUnit := oldItem.Quantity, oldItem.Unit
 	item := listState.EditItem(args)
 	if item == nil {
 		return
@@ -404,12 +920,26 @@ func (l *LiveEditor) HandleEditItem(args *EditItemArgs, conn *connection) {
 	s := ""
 	buf := bytes.NewBufferString(s)
 	color := l.GetColaboratorOnline(conn.ListId, conn.User.Id).Color
-	i := *views.NewIndexedItem(args.GroupIndex, args.ItemIndex, item, color, nil, fmt.Sprintf("outerHTML:#desc-%d-%d", args.GroupIndex, args.ItemIndex))
-	if args.Field == "description" {
+	i := itemArgs(listState, args.GroupIndex, args.ItemIndex, item, color, fmt.Sprintf("outerHTML:#desc-%d-%d", args.GroupIndex, args.ItemIndex))
+	switch args.Field {
+	case "description":
 		views.Templates.RenderItemDescription(buf, i)
-	} else if args.Field != "quantity" {
+		// The description may have carried a quantity, e.g. "2 kg tomatoes"
+		if item.Quantity != oldQuantity || item.Unit != oldUnit {
+			views.Templates.RenderItemQuantity(buf, i)
+			views.Templates.RenderItemUnit(buf, i)
+		}
+	case "quantity":
 		views.Templates.RenderItemQuantity(buf, i)
+	case "unit":
+		views.Templates.RenderItemUnit(buf, i)
+	case "price", "currency":
+		views.Templates.RenderItemPrice(buf, i)
+	case "note":
+		views.Templates.RenderItemNote(buf, i)
 	}
+	renderGroupTotal(buf, listState, listState.FindGroupById(args.GroupIndex))
+	renderBudget(buf, listState)
 	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *views.NewListUi(listState.Ui.List, conn.User), IsDirty: true})
 	for _, conn := range l.GetConnectionsOfList(conn.ListId) {
 		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
@@ -421,15 +951,28 @@ type DeleteItemArgs struct {
 	ItemIndex  int64 `json:"itemIndex"`
 }
 
-func (l *LiveEditor) GetCurrentListUi(listId int64) *views.ListUi {
-	list, ok := l.listsById[listId]
-	if ok {
-		return list.Ui
+// Snapshot copies the list being edited, with its unsaved changes, so it can be read
+// after the editor goes on changing it. It returns nil when nobody is editing the list.
+func (l *LiveEditor) Snapshot(listId int64) (ui *views.ListUi, dirty bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(listId)
+	if listState == nil {
+		return nil, false
 	}
-	return nil
+	ui = &views.ListUi{
+		List:               listState.Ui.List.Clone(),
+		ColaboratorsOnline: slices.Clone(listState.Ui.ColaboratorsOnline),
+		LastUsed:           listState.Ui.LastUsed,
+	}
+	if trip := listState.Ui.Trip; trip != nil {
+		ui.Trip = &views.TripUi{Shopper: trip.Shopper, StartedAt: trip.StartedAt, PickedBy: maps.Clone(trip.PickedBy)}
+	}
+	return ui, listState.Dirty
 }
 
-func (l *LiveEditor) GetCurrentListState(listId int64) *ListState {
+// state returns the state of the list being edited, or nil.
+func (l *LiveEditor) state(listId int64) *ListState {
 	list, ok := l.listsById[listId]
 	if ok {
 		return list
@@ -437,6 +980,160 @@ func (l *LiveEditor) GetCurrentListState(listId int64) *ListState {
 	return nil
 }
 
+func (l *LiveEditor) currentList(listId int64) *list.List {
+	listState := l.state(listId)
+	if listState == nil {
+		return nil
+	}
+	return listState.Ui.List
+}
+
+// Reload replaces the edited list with the saved one and renders it again for everyone editing it.
+func (l *LiveEditor) Reload(listId int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.reload(listId)
+}
+
+func (l *LiveEditor) reload(listId int64) {
+	listState := l.state(listId)
+	if listState == nil {
+		return
+	}
+	saved, err := l.listRepository.Get(listId)
+	if err != nil {
+		log.Println("Error reloading list", listId, err)
+		return
+	}
+	l.replace(listState, &saved)
+}
+
+// Replace shows the list, just saved apart from the editor, to everyone editing it
+// instead of their unsaved changes.
+func (l *LiveEditor) Replace(listId int64, saved *list.List) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if listState := l.state(listId); listState != nil {
+		l.replace(listState, saved)
+	}
+}
+
+// Save saves the list being edited with its unsaved changes, then shows the saved list,
+// where new groups and items have their ids, to its editors. It returns a copy of the
+// saved list, or nil when nobody is editing the list.
+func (l *LiveEditor) Save(listId int64) (*list.List, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(listId)
+	if listState == nil {
+		return nil, nil
+	}
+	if _, err := l.listRepository.Update(listState.Ui.List); err != nil {
+		return nil, err
+	}
+	l.reload(listId)
+	return listState.Ui.List.Clone(), nil
+}
+
+func (l *LiveEditor) replace(listState *ListState, saved *list.List) {
+	listState.Replace(saved)
+	s := ""
+	buf := bytes.NewBufferString(s)
+	views.Templates.RenderGroups(buf, listState.Ui)
+	views.Templates.RenderOccurrence(buf, listState.Ui)
+	renderBudget(buf, listState)
+	renderTripIfActive(buf, listState)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *listState.Ui, IsDirty: false})
+	for _, conn := range l.GetConnectionsOfList(listState.Ui.List.Id) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+// Edit applies the change to the list holding the lock. When someone is editing the list,
+// the change joins their unsaved changes and is shown to them, with ids given to the new
+// groups and items. Otherwise the saved list is changed.
+func (l *LiveEditor) Edit(listId int64, change func(l *list.List) bool) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(listId)
+	if listState == nil {
+		saved, err := l.listRepository.Get(listId)
+		if err != nil {
+			return err
+		}
+		if !change(&saved) {
+			return nil
+		}
+		_, err = l.listRepository.Update(&saved)
+		return err
+	}
+	if !change(listState.Ui.List) {
+		return nil
+	}
+	listState.assignIds()
+	listState.Dirty = true
+	s := ""
+	buf := bytes.NewBufferString(s)
+	views.Templates.RenderGroups(buf, listState.Ui)
+	renderBudget(buf, listState)
+	renderTripIfActive(buf, listState)
+	views.Templates.RenderSaveList(buf, &views.ListArgs{List: *listState.Ui, IsDirty: true})
+	for _, conn := range l.GetConnectionsOfList(listId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+	return nil
+}
+
+// SetItemAttachments shows the attachments of the item, after one was added or removed,
+// to everyone editing its list.
+func (l *LiveEditor) SetItemAttachments(listId, groupId, itemId int64, attachments []attachment.Attachment) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(listId)
+	if listState == nil {
+		return
+	}
+	item := listState.FindItemById(groupId, itemId)
+	if item == nil {
+		return
+	}
+	item.Attachments = attachments
+	s := ""
+	buf := bytes.NewBufferString(s)
+	views.Templates.RenderItemAttachments(buf, itemArgs(listState, groupId, itemId, item, "", ""))
+	for _, conn := range l.GetConnectionsOfList(listId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+// RestoreGroup brings back a group restored from the trash to everyone editing its list.
+func (l *LiveEditor) RestoreGroup(listId, groupId int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(listId)
+	if listState == nil || listState.Ui.List.RestoreGroup(groupId) == nil {
+		return
+	}
+	s := ""
+	buf := bytes.NewBufferString(s)
+	views.Templates.RenderGroups(buf, listState.Ui)
+	renderBudget(buf, listState)
+	renderTripIfActive(buf, listState)
+	for _, conn := range l.GetConnectionsOfList(listId) {
+		conn.Conn.WriteMessage(websocket.TextMessage, buf.Bytes())
+	}
+}
+
+// PurgeGroup forgets a group purged from the trash, so saving the list does not bring it back.
+func (l *LiveEditor) PurgeGroup(listId, groupId int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	listState := l.state(listId)
+	if listState != nil {
+		listState.Ui.List.PurgeGroup(groupId)
+	}
+}
+
 func (l *LiveEditor) SetDirty(listId int64) {
 	list, ok := l.listsById[listId]
 	if ok {
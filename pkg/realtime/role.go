@@ -0,0 +1,49 @@
+package realtime
+
+import (
+	"encoding/json"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/list"
+)
+
+// allows tells whether the connection can take the action. Guests following the list
+// through a link only check items off, when the link lets them.
+func (l *LiveEditor) allows(conn *connection, actionType int, p []byte) bool {
+	if conn.Share != nil {
+		return actionType == ACTION_CHECK_ITEM && conn.Share.CanCheck && conn.Share.IsActive(time.Now())
+	}
+	return allowed(l.roleOf(conn), actionType, p)
+}
+
+// allowed tells whether a member with the role can take the action. Every member following
+// the list can show where they are in it, commenters can also write the notes of the items and
+// editors can do everything else. Who is not a member, with no role, can do nothing.
+func allowed(role list.Role, actionType int, p []byte) bool {
+	if role == "" {
+		return false
+	}
+	switch actionType {
+	case ACTION_FOCUS_ITEM, ACTION_UNFOCUS_ITEM, ACTION_UPDATE_COLOR:
+		return true
+	case ACTION_EDIT_ITEM:
+		if role.CanEdit() {
+			return true
+		}
+		var editItemArgs EditItemArgs
+		if err := json.Unmarshal(p, &editItemArgs); err != nil {
+			return false
+		}
+		return role.CanComment() && editItemArgs.Field == "note"
+	}
+	return role.CanEdit()
+}
+
+// roleOf returns the role of the user of the connection in the list being edited.
+func (l *LiveEditor) roleOf(conn *connection) list.Role {
+	current := l.currentList(conn.ListId)
+	if current == nil {
+		return ""
+	}
+	return current.RoleOf(conn.User.Id)
+}
@@ -1,9 +1,13 @@
 package realtime
 
 import (
-	"strconv"
+	"strings"
+	"time"
 
 	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/quickadd"
+	"vilmasoftware.com/colablists/pkg/trip"
+	"vilmasoftware.com/colablists/pkg/units"
 	"vilmasoftware.com/colablists/pkg/user"
 	"vilmasoftware.com/colablists/pkg/views"
 )
@@ -14,26 +18,175 @@ type ListState struct {
 	Dirty            bool
 	groupIdGenerator *Generator
 	itemIdGenerator  *Generator
+	// Undo history of each user, so undoing only takes back what the user did
+	undoStacks map[int64][]*undoEntry
 }
 
-func NewListState(list *list.List, user *user.User, conn *connection) *ListState {
-	groupIds := make([]int64, 0)
-	itemIds := make([]int64, 0)
-	for _, group := range list.Groups {
-		groupIds = append(groupIds, group.GroupId)
-		for _, item := range group.Items {
-			itemIds = append(itemIds, item.Id)
+const maxUndoEntries = 50
+
+// undoEntry reverts one operation as a unit. GroupIds are the groups touched
+// by the operation, which have to be rendered again after reverting it.
+type undoEntry struct {
+	groupIds []int64
+	revert   func()
+}
+
+func (ls *ListState) pushUndo(userId int64, groupIds []int64, revert func()) {
+	stack := append(ls.undoStacks[userId], &undoEntry{groupIds: groupIds, revert: revert})
+	if len(stack) > maxUndoEntries {
+		stack = stack[len(stack)-maxUndoEntries:]
+	}
+	ls.undoStacks[userId] = stack
+}
+
+// itemChange is an item before and after an operation, nil when it did not exist.
+// Index is where the item was in its group before the operation.
+type itemChange struct {
+	groupId int64
+	index   int
+	before  *list.Item
+	after   *list.Item
+}
+
+// pushItemsUndo lets the user revert the changes of an operation on the items of the groups.
+// Items changed since by someone else are left as they are, so undoing never takes back
+// what other users did.
+func (ls *ListState) pushItemsUndo(userId int64, groupIds []int64, changes []itemChange) {
+	if len(changes) == 0 {
+		return
+	}
+	ls.pushUndo(userId, groupIds, func() {
+		for _, change := range changes {
+			ls.revertItem(change)
+		}
+	})
+}
+
+func (ls *ListState) revertItem(change itemChange) {
+	group := ls.FindGroupById(change.groupId)
+	if group == nil {
+		return
+	}
+	position := -1
+	id := change.after
+	if id == nil {
+		id = change.before
+	}
+	for i, item := range group.Items {
+		if item.Id == id.Id {
+			position = i
+			break
+		}
+	}
+	switch {
+	case change.before == nil:
+		if position >= 0 && sameItem(group.Items[position], change.after) {
+			group.Items = append(group.Items[:position], group.Items[position+1:]...)
+		}
+	case change.after == nil:
+		if position < 0 {
+			restored := *change.before
+			index := min(change.index, len(group.Items))
+			group.Items = append(group.Items[:index], append([]*list.Item{&restored}, group.Items[index:]...)...)
+		}
+	default:
+		if position >= 0 && sameItem(group.Items[position], change.after) {
+			current := group.Items[position]
+			restored := *change.before
+			// Attachments are saved as they are uploaded, apart from the list
+			restored.Attachments = current.Attachments
+			*current = restored
+		}
+	}
+}
+
+// sameItem tells whether the item still has the values it was left with.
+func sameItem(current *list.Item, recorded *list.Item) bool {
+	return current.Description == recorded.Description &&
+		current.Quantity == recorded.Quantity &&
+		current.Unit == recorded.Unit &&
+		current.Order == recorded.Order &&
+		current.Checked == recorded.Checked &&
+		current.Currency == recorded.Currency &&
+		current.Note == recorded.Note &&
+		samePointee(current.UnitPrice, recorded.UnitPrice) &&
+		samePointee(current.AssigneeId, recorded.AssigneeId)
+}
+
+func samePointee[T comparable](a *T, b *T) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// Undo reverts the last undoable operation of the user, returning the ids of the groups it touched.
+func (ls *ListState) Undo(userId int64) ([]int64, bool) {
+	stack := ls.undoStacks[userId]
+	if len(stack) == 0 {
+		return nil, false
+	}
+	entry := stack[len(stack)-1]
+	ls.undoStacks[userId] = stack[:len(stack)-1]
+	entry.revert()
+	ls.Dirty = true
+	return entry.groupIds, true
+}
+
+// usedIds returns the ids of the groups and items of the list, including the ones
+// in the trash, which new ones must not take.
+func usedIds(l *list.List) (groupIds []int64, itemIds []int64) {
+	for _, groups := range [][]*list.Group{l.Groups, l.TrashedGroups} {
+		for _, group := range groups {
+			groupIds = append(groupIds, group.GroupId)
+			for _, item := range group.Items {
+				itemIds = append(itemIds, item.Id)
+			}
 		}
 	}
-	return &ListState{
+	return groupIds, itemIds
+}
+
+func NewListState(list *list.List, user *user.User, conn *connection) *ListState {
+	groupIds, itemIds := usedIds(list)
+	ls := &ListState{
 		Ui: &views.ListUi{
 			List:               list,
-			ColaboratorsOnline: []*views.UserUi{{User: user, Color: "#18d825"}},
+			ColaboratorsOnline: []*views.UserUi{},
 		},
 		connections:      []*connection{conn},
 		groupIdGenerator: NewGenerator(maxSlice(groupIds) + 1),
 		itemIdGenerator:  NewGenerator(maxSlice(itemIds) + 1),
+		undoStacks:       make(map[int64][]*undoEntry),
+	}
+	ls.AddColaboratorOnline(user)
+	return ls
+}
+
+// Replace swaps the edited list for its saved version, e.g. after it was changed
+// outside of the editor. Undo history refers to the replaced items and is dropped.
+func (ls *ListState) Replace(l *list.List) {
+	groupIds, itemIds := usedIds(l)
+	ls.Ui.List = l
+	ls.groupIdGenerator.SkipTo(maxSlice(groupIds) + 1)
+	ls.itemIdGenerator.SkipTo(maxSlice(itemIds) + 1)
+	ls.undoStacks = make(map[int64][]*undoEntry)
+	ls.Dirty = false
+}
+
+// AddColaboratorOnline marks the user as online in the list, giving it its saved color
+// or, when another online colaborator already uses it, a distinct one from the palette.
+func (ls *ListState) AddColaboratorOnline(u *user.User) *views.UserUi {
+	taken := make([]string, 0, len(ls.Ui.ColaboratorsOnline))
+	for _, userUi := range ls.Ui.ColaboratorsOnline {
+		if userUi.Id == u.Id {
+			return userUi
+		}
+		taken = append(taken, userUi.Color)
 	}
+	userUi := &views.UserUi{User: u, Color: u.PickColor(taken)}
+	ls.Ui.ColaboratorsOnline = append(ls.Ui.ColaboratorsOnline, userUi)
+	return userUi
 }
 
 func (ls *ListState) FindGroupById(groupId int64) *list.Group {
@@ -68,6 +221,22 @@ func maxSlice(arr []int64) int64 {
 	return result
 }
 
+// assignIds gives ids of the edited list to the groups and items added without one.
+func (ls *ListState) assignIds() {
+	for _, group := range ls.Ui.List.Groups {
+		if group.GroupId == 0 {
+			group.GroupId = ls.groupIdGenerator.Next()
+		}
+		for _, item := range group.Items {
+			item.GroupId = group.GroupId
+			if item.Id == 0 {
+				item.Id = ls.itemIdGenerator.Next()
+				item.Order = item.Id
+			}
+		}
+	}
+}
+
 func (ls *ListState) AddGroup(groupText string) *list.Group {
 	groupId := ls.groupIdGenerator.Next()
 	group := &list.Group{GroupId: groupId, Name: groupText, Items: []*list.Item{{
@@ -91,22 +260,38 @@ func (ls *ListState) AddItem(groupId int64, itemText string) *list.Item {
 	item := &list.Item{
 		Order:       itemId,
 		GroupId:     groupId,
-		Description: "New Item",
+		Description: itemText,
 		Id:          itemId,
 		Quantity:    1,
 	}
+	applyQuickAdd(item, itemText)
 	group.Items = append(group.Items, item)
 	ls.Dirty = true
 	return item
 }
 
+// AddItems adds one item per text to the group. Undoing it removes all of them at once.
+func (ls *ListState) AddItems(userId int64, groupId int64, itemTexts []string) []*list.Item {
+	group := ls.FindGroupById(groupId)
+	if group == nil || len(itemTexts) == 0 {
+		return nil
+	}
+	items := make([]*list.Item, 0, len(itemTexts))
+	changes := make([]itemChange, 0, len(itemTexts))
+	for _, text := range itemTexts {
+		item := ls.AddItem(groupId, text)
+		added := *item
+		items = append(items, item)
+		changes = append(changes, itemChange{groupId: groupId, index: len(group.Items) - 1, after: &added})
+	}
+	ls.pushItemsUndo(userId, []int64{groupId}, changes)
+	return items
+}
+
+// DeleteGroup moves the group to the trash, from where it can be restored after saving.
 func (ls *ListState) DeleteGroup(groupId int64) {
-	for i, group := range ls.Ui.List.Groups {
-		if group.GroupId == groupId {
-			ls.Ui.List.Groups = append(ls.Ui.List.Groups[:i], ls.Ui.List.Groups[i+1:]...)
-			ls.Dirty = true
-			return
-		}
+	if ls.Ui.List.TrashGroup(groupId, time.Now()) != nil {
+		ls.Dirty = true
 	}
 }
 
@@ -126,14 +311,39 @@ func (ls *ListState) EditItem(args *EditItemArgs) *list.Item {
 		return nil
 	}
 
-	qtd, err := strconv.Atoi(args.Quantity)
-	if err != nil {
-		return nil
-	}
-	if args.Field == "description" {
+	switch args.Field {
+	case "description":
 		item.Description = args.Description
-	} else if args.Field == "quantity" {
+	case "quantity":
+		qtd, err := units.ParseAmount(args.Quantity)
+		if err != nil {
+			return nil
+		}
 		item.Quantity = qtd
+	case "unit":
+		unit, ok := units.Normalize(args.Unit)
+		if !ok {
+			return nil
+		}
+		item.Unit = unit
+	case "price":
+		if strings.TrimSpace(args.Price) == "" {
+			item.UnitPrice = nil
+			break
+		}
+		price, err := units.ParseAmount(args.Price)
+		if err != nil {
+			return nil
+		}
+		item.UnitPrice = &price
+	case "currency":
+		currency, ok := list.NormalizeCurrency(args.Currency)
+		if !ok {
+			return nil
+		}
+		item.Currency = currency
+	case "note":
+		item.Note = strings.TrimSpace(args.Note)
 	}
 	ls.Dirty = true
 	return item
@@ -153,3 +363,201 @@ func (ls *ListState) DeleteItem(groupId, itemId int64) {
 	}
 	ls.Dirty = true
 }
+
+// applyQuickAdd fills quantity, unit and description of the item from text such as "2 kg tomatoes".
+func applyQuickAdd(item *list.Item, text string) {
+	parsed := quickadd.Parse(text)
+	if !parsed.HasQuantity {
+		return
+	}
+	item.Quantity = parsed.Quantity
+	item.Unit = parsed.Unit
+	item.Description = parsed.Description
+}
+
+// groupsInScope returns the group with the given id, or every group when groupId is nil.
+func (ls *ListState) groupsInScope(groupId *int64) []*list.Group {
+	if groupId == nil {
+		return ls.Ui.List.Groups
+	}
+	group := ls.FindGroupById(*groupId)
+	if group == nil {
+		return []*list.Group{}
+	}
+	return []*list.Group{group}
+}
+
+func groupIds(groups []*list.Group) []int64 {
+	ids := make([]int64, 0, len(groups))
+	for _, group := range groups {
+		ids = append(ids, group.GroupId)
+	}
+	return ids
+}
+
+func (ls *ListState) ToggleItem(groupId, itemId int64) *list.Item {
+	item := ls.FindItemById(groupId, itemId)
+	if item == nil {
+		return nil
+	}
+	if item.Checked == 0 {
+		item.Checked = 1
+	} else {
+		item.Checked = 0
+	}
+	ls.Dirty = true
+	return item
+}
+
+// AssignItem sets who buys the item, nil for nobody. The assignee must be a member of the list.
+func (ls *ListState) AssignItem(groupId, itemId int64, assigneeId *int64) *list.Item {
+	item := ls.FindItemById(groupId, itemId)
+	if item == nil {
+		return nil
+	}
+	if assigneeId != nil && !ls.Ui.List.IsMember(*assigneeId) {
+		return nil
+	}
+	item.AssigneeId = assigneeId
+	ls.Dirty = true
+	return item
+}
+
+// SetChecked checks or unchecks every item of a group, or of the list when groupId is nil,
+// returning the groups and the items that changed.
+func (ls *ListState) SetChecked(userId int64, groupId *int64, checked bool) ([]*list.Group, []*list.Item) {
+	groups := ls.groupsInScope(groupId)
+	value := int8(0)
+	if checked {
+		value = 1
+	}
+	changed := make([]*list.Item, 0)
+	changes := make([]itemChange, 0)
+	for _, group := range groups {
+		for i, item := range group.Items {
+			if item.Checked == value {
+				continue
+			}
+			before := *item
+			item.Checked = value
+			after := *item
+			changed = append(changed, item)
+			changes = append(changes, itemChange{groupId: group.GroupId, index: i, before: &before, after: &after})
+		}
+	}
+	ls.pushItemsUndo(userId, groupIds(groups), changes)
+	ls.Dirty = true
+	return groups, changed
+}
+
+// ClearChecked removes checked items of a group, or of the list when groupId is nil.
+func (ls *ListState) ClearChecked(userId int64, groupId *int64) []*list.Group {
+	groups := ls.groupsInScope(groupId)
+	changes := make([]itemChange, 0)
+	for _, group := range groups {
+		kept := make([]*list.Item, 0, len(group.Items))
+		for i, item := range group.Items {
+			if item.Checked == 0 {
+				kept = append(kept, item)
+				continue
+			}
+			removed := *item
+			changes = append(changes, itemChange{groupId: group.GroupId, index: i, before: &removed})
+		}
+		group.Items = kept
+	}
+	ls.pushItemsUndo(userId, groupIds(groups), changes)
+	ls.Dirty = true
+	return groups
+}
+
+// DuplicateGroup appends a copy of the group and its items to the list.
+func (ls *ListState) DuplicateGroup(userId int64, groupId int64) *list.Group {
+	original := ls.FindGroupById(groupId)
+	if original == nil {
+		return nil
+	}
+	newGroupId := ls.groupIdGenerator.Next()
+	group := &list.Group{
+		GroupId: newGroupId,
+		ListId:  original.ListId,
+		Name:    original.Name + " (copy)",
+		Items:   make([]*list.Item, 0, len(original.Items)),
+	}
+	for _, item := range original.Items {
+		copied := *item
+		copied.Id = ls.itemIdGenerator.Next()
+		copied.GroupId = newGroupId
+		copied.Order = copied.Id
+		group.Items = append(group.Items, &copied)
+	}
+	ls.Ui.List.Groups = append(ls.Ui.List.Groups, group)
+	ls.pushUndo(userId, []int64{newGroupId}, func() {
+		// The copy was never saved, there is nothing to keep in the trash
+		groups := ls.Ui.List.Groups
+		for i, g := range groups {
+			if g.GroupId == newGroupId {
+				ls.Ui.List.Groups = append(groups[:i], groups[i+1:]...)
+				return
+			}
+		}
+	})
+	ls.Dirty = true
+	return group
+}
+
+// StartTrip marks the user as shopping for the list. Only one trip runs at a time.
+func (ls *ListState) StartTrip(shopper *user.User) bool {
+	if ls.Ui.Trip != nil {
+		return false
+	}
+	ls.Ui.Trip = &views.TripUi{
+		Shopper:   shopper,
+		StartedAt: time.Now(),
+		PickedBy:  make(map[int64]*user.User),
+	}
+	return true
+}
+
+// RecordPick keeps who checked or unchecked the item during the current trip.
+func (ls *ListState) RecordPick(item *list.Item, picker *user.User) {
+	if ls.Ui.Trip == nil {
+		return
+	}
+	if item.Checked != 0 {
+		ls.Ui.Trip.PickedBy[item.Id] = picker
+	} else {
+		delete(ls.Ui.Trip.PickedBy, item.Id)
+	}
+}
+
+// EndTrip finishes the current trip and returns its summary, which is not saved yet.
+func (ls *ListState) EndTrip() *trip.Trip {
+	current := ls.Ui.Trip
+	if current == nil {
+		return nil
+	}
+	ls.Ui.Trip = nil
+	summary := &trip.Trip{
+		ListId:       ls.Ui.List.Id,
+		ListTitle:    ls.Ui.List.Title,
+		Shopper:      *current.Shopper,
+		StartedAt:    current.StartedAt,
+		EndedAt:      time.Now(),
+		MissingItems: []string{},
+	}
+	if ls.Ui.List.Community != nil {
+		summary.CommunityId = &ls.Ui.List.Community.CommunityId
+	}
+	for _, group := range ls.Ui.List.Groups {
+		for _, item := range group.Items {
+			if item.Checked != 0 {
+				summary.ItemsBought++
+			} else {
+				summary.ItemsMissing++
+				summary.MissingItems = append(summary.MissingItems, item.Description)
+			}
+		}
+	}
+	return summary
+}
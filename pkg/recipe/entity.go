@@ -0,0 +1,88 @@
+// Package recipe is the library of recipes of users and communities, whose
+// ingredients can be added to lists.
+package recipe
+
+import (
+	"errors"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/quickadd"
+	"vilmasoftware.com/colablists/pkg/units"
+	"vilmasoftware.com/colablists/pkg/user"
+)
+
+type Recipe struct {
+	Id           int64
+	Title        string
+	Instructions string
+	// Number of servings the quantities of the ingredients are for
+	Servings int
+	Creator  user.User
+	// Community the recipe is shared with, nil when only its creator sees it
+	CommunityId *int64
+	Ingredients []Ingredient
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
+}
+
+type Ingredient struct {
+	Id          int64
+	Description string
+	Quantity    float64
+	// Symbol of a unit from pkg/units
+	Unit string
+}
+
+var ErrNotFound = errors.New("recipe not found")
+
+func (i Ingredient) QuantityWithUnit() units.Quantity {
+	return units.Quantity{Amount: i.Quantity, Unit: i.Unit}
+}
+
+// String writes the ingredient as it is quick added, e.g. "500 g flour" or "3 eggs".
+func (i Ingredient) String() string {
+	if i.Unit == "" {
+		return units.FormatAmount(i.Quantity) + " " + i.Description
+	}
+	return i.QuantityWithUnit().String() + " " + i.Description
+}
+
+// ParseIngredients reads one ingredient per line, written as items are quick added to lists,
+// e.g. "500 g flour" or "eggs x3".
+func ParseIngredients(text string) []Ingredient {
+	ingredients := make([]Ingredient, 0)
+	for _, line := range strings.Split(text, "\n") {
+		parsed := quickadd.Parse(strings.TrimSpace(line))
+		if parsed.Description == "" {
+			continue
+		}
+		ingredients = append(ingredients, Ingredient{Description: parsed.Description, Quantity: parsed.Quantity, Unit: parsed.Unit})
+	}
+	return ingredients
+}
+
+func (r Recipe) IsSharedWith(communityId int64) bool {
+	return r.CommunityId != nil && *r.CommunityId == communityId
+}
+
+// IngredientsText writes the ingredients one per line, as ParseIngredients reads them.
+func (r Recipe) IngredientsText() string {
+	lines := make([]string, 0, len(r.Ingredients))
+	for _, ingredient := range r.Ingredients {
+		lines = append(lines, ingredient.String())
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Scale returns the ingredients with their quantities for the number of servings.
+func (r Recipe) Scale(servings int) []Ingredient {
+	scaled := make([]Ingredient, 0, len(r.Ingredients))
+	for _, ingredient := range r.Ingredients {
+		if servings > 0 && r.Servings > 0 {
+			ingredient.Quantity = units.Round(ingredient.Quantity * float64(servings) / float64(r.Servings))
+		}
+		scaled = append(scaled, ingredient)
+	}
+	return scaled
+}
@@ -0,0 +1,11 @@
+package recipe
+
+type RecipesRepository interface {
+	// FindVisible returns the recipes of the user and of the communities they belong to, by title.
+	FindVisible(userId int64) ([]Recipe, error)
+	// Get returns the recipe with its ingredients, or ErrNotFound.
+	Get(recipeId int64) (Recipe, error)
+	// Save inserts the recipe when it has no id yet, writing it back, or updates it, replacing its ingredients.
+	Save(recipe *Recipe) error
+	Delete(recipeId int64) error
+}
@@ -0,0 +1,54 @@
+package recipe
+
+import (
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/list"
+)
+
+// LiveLists are the lists being edited, whose unsaved changes are the most recent state.
+type LiveLists interface {
+	// Edit applies the change to the list while nobody else changes it. When someone is editing
+	// the list, the change joins their unsaved changes, otherwise it is saved right away.
+	// The change returns whether it changed anything.
+	Edit(listId int64, change func(l *list.List) bool) error
+	// Save saves the unsaved changes of the list, when someone is editing it.
+	Save(listId int64) (*list.List, error)
+}
+
+type Service struct {
+	Live LiveLists
+}
+
+// AddToList adds the ingredients of the recipe, scaled to the servings, to the group of the list
+// with the name, created when there is none. Ingredients already on the group to buy have their
+// quantities added up.
+func (s *Service) AddToList(recipe *Recipe, listId int64, groupName string, servings int) error {
+	groupName = strings.TrimSpace(groupName)
+	if groupName == "" {
+		groupName = recipe.Title
+	}
+	err := s.Live.Edit(listId, func(current *list.List) bool {
+		var group *list.Group
+		for _, g := range current.Groups {
+			if strings.EqualFold(strings.TrimSpace(g.Name), groupName) {
+				group = g
+				break
+			}
+		}
+		if group == nil {
+			group = &list.Group{ListId: listId, Name: groupName}
+			current.Groups = append(current.Groups, group)
+		}
+		for _, ingredient := range recipe.Scale(servings) {
+			group.AddItem(&list.Item{Description: ingredient.Description, Quantity: ingredient.Quantity, Unit: ingredient.Unit})
+		}
+		return true
+	})
+	if err != nil {
+		return err
+	}
+	// The ingredients are saved along with the unsaved changes of the editors
+	_, err = s.Live.Save(listId)
+	return err
+}
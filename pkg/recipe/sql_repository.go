@@ -0,0 +1,182 @@
+package recipe
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlRecipesRepository struct{}
+
+const recipeColumns = `r.recipeId, r.title, r.instructions, r.servings, r.creatorLuserId, COALESCE(u.username, ''), COALESCE(u.avatarUrl, ''),
+  r.communityId, r.createdAt, r.updatedAt`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanRecipe(row scanner) (Recipe, error) {
+	r := Recipe{}
+	err := row.Scan(&r.Id, &r.Title, &r.Instructions, &r.Servings, &r.Creator.Id, &r.Creator.Username, &r.Creator.AvatarUrl,
+		&r.CommunityId, &r.CreatedAt, &r.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r, ErrNotFound
+	}
+	return r, err
+}
+
+// FindVisible implements RecipesRepository.
+func (s *SqlRecipesRepository) FindVisible(userId int64) ([]Recipe, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT `+recipeColumns+`
+    FROM recipe r
+    LEFT JOIN luser u ON u.luserId = r.creatorLuserId
+    WHERE r.creatorLuserId = ?
+    OR r.communityId IN (SELECT communityId FROM community WHERE createdByLuserId = ?)
+    OR r.communityId IN (SELECT communityId FROM community_members WHERE memberId = ?)
+    ORDER BY r.title COLLATE NOCASE
+  `, userId, userId, userId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	recipes := make([]Recipe, 0)
+	for rows.Next() {
+		r, err := scanRecipe(rows)
+		if err != nil {
+			return nil, err
+		}
+		recipes = append(recipes, r)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	for i := range recipes {
+		if recipes[i].Ingredients, err = getIngredients(db, recipes[i].Id); err != nil {
+			return nil, err
+		}
+	}
+	return recipes, nil
+}
+
+// Get implements RecipesRepository.
+func (s *SqlRecipesRepository) Get(recipeId int64) (Recipe, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Recipe{}, err
+	}
+	defer db.Close()
+	r, err := scanRecipe(db.QueryRow(`
+    SELECT `+recipeColumns+`
+    FROM recipe r
+    LEFT JOIN luser u ON u.luserId = r.creatorLuserId
+    WHERE r.recipeId = ?
+  `, recipeId))
+	if err != nil {
+		return Recipe{}, err
+	}
+	r.Ingredients, err = getIngredients(db, recipeId)
+	return r, err
+}
+
+func getIngredients(tx infra.Queryable, recipeId int64) ([]Ingredient, error) {
+	rows, err := tx.Query(`
+    SELECT ingredientId, description, quantity, unit
+    FROM recipe_ingredient
+    WHERE recipeId = ?
+    ORDER BY order_, ingredientId
+  `, recipeId)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ingredients := make([]Ingredient, 0)
+	for rows.Next() {
+		i := Ingredient{}
+		if err := rows.Scan(&i.Id, &i.Description, &i.Quantity, &i.Unit); err != nil {
+			return nil, err
+		}
+		ingredients = append(ingredients, i)
+	}
+	return ingredients, rows.Err()
+}
+
+// Save implements RecipesRepository.
+func (s *SqlRecipesRepository) Save(recipe *Recipe) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	recipe.UpdatedAt = time.Now().UTC()
+	if recipe.Id == 0 {
+		recipe.CreatedAt = recipe.UpdatedAt
+		result, err := tx.Exec(`
+      INSERT INTO recipe (title, instructions, servings, creatorLuserId, communityId, createdAt, updatedAt)
+      VALUES (?, ?, ?, ?, ?, ?, ?)
+    `, recipe.Title, recipe.Instructions, recipe.Servings, recipe.Creator.Id, recipe.CommunityId, recipe.CreatedAt, recipe.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if recipe.Id, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	} else {
+		_, err = tx.Exec(`
+      UPDATE recipe SET title = ?, instructions = ?, servings = ?, communityId = ?, updatedAt = ?
+      WHERE recipeId = ?
+    `, recipe.Title, recipe.Instructions, recipe.Servings, recipe.CommunityId, recipe.UpdatedAt, recipe.Id)
+		if err != nil {
+			return err
+		}
+		if _, err = tx.Exec(`DELETE FROM recipe_ingredient WHERE recipeId = ?`, recipe.Id); err != nil {
+			return err
+		}
+	}
+	for order, ingredient := range recipe.Ingredients {
+		result, err := tx.Exec(`
+      INSERT INTO recipe_ingredient (recipeId, description, quantity, unit, order_)
+      VALUES (?, ?, ?, ?, ?)
+    `, recipe.Id, ingredient.Description, ingredient.Quantity, ingredient.Unit, order)
+		if err != nil {
+			return err
+		}
+		if recipe.Ingredients[order].Id, err = result.LastInsertId(); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// Delete implements RecipesRepository.
+func (s *SqlRecipesRepository) Delete(recipeId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if _, err = tx.Exec(`DELETE FROM recipe_ingredient WHERE recipeId = ?`, recipeId); err != nil {
+		return err
+	}
+	if _, err = tx.Exec(`DELETE FROM recipe WHERE recipeId = ?`, recipeId); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
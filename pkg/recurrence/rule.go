@@ -0,0 +1,166 @@
+package recurrence
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Presets accepted besides cron expressions.
+var presets = map[string]string{
+	"daily":    "0 0 * * *",
+	"@daily":   "0 0 * * *",
+	"weekly":   "0 0 * * 0",
+	"@weekly":  "0 0 * * 0",
+	"monthly":  "0 0 1 * *",
+	"@monthly": "0 0 1 * *",
+}
+
+var monthNames = []string{"jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}
+var weekdayNames = []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}
+
+// Schedule is a parsed rule, a subset of cron: "minute hour day-of-month month day-of-week"
+// where each field is *, a number or name, a range a-b, a list a,b and optionally a step /n.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// When both day fields are restricted a day matches either, as in cron
+	domRestricted, dowRestricted bool
+}
+
+type field struct {
+	min, max int
+	names    []string
+	// Index of the first name, months start at 1
+	namesFrom int
+}
+
+var (
+	minuteField = field{min: 0, max: 59}
+	hourField   = field{min: 0, max: 23}
+	domField    = field{min: 1, max: 31}
+	monthField  = field{min: 1, max: 12, names: monthNames, namesFrom: 1}
+	dowField    = field{min: 0, max: 7, names: weekdayNames}
+)
+
+// Parse parses a preset (daily, weekly, monthly) or a cron expression.
+func Parse(rule string) (*Schedule, error) {
+	rule = strings.ToLower(strings.TrimSpace(rule))
+	if preset, ok := presets[rule]; ok {
+		rule = preset
+	}
+	fields := strings.Fields(rule)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("rule %q should be daily, weekly, monthly or have 5 fields: minute hour day-of-month month day-of-week", rule)
+	}
+	s := &Schedule{}
+	var err error
+	if s.minute, err = minuteField.parse(fields[0]); err != nil {
+		return nil, err
+	}
+	if s.hour, err = hourField.parse(fields[1]); err != nil {
+		return nil, err
+	}
+	if s.dom, err = domField.parse(fields[2]); err != nil {
+		return nil, err
+	}
+	if s.month, err = monthField.parse(fields[3]); err != nil {
+		return nil, err
+	}
+	if s.dow, err = dowField.parse(fields[4]); err != nil {
+		return nil, err
+	}
+	// 7 is also sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	s.domRestricted = fields[2] != "*"
+	s.dowRestricted = fields[4] != "*"
+	return s, nil
+}
+
+func (f field) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		step := 1
+		if rangePart, stepPart, ok := strings.Cut(part, "/"); ok {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part, step = rangePart, n
+		}
+		from, to := f.min, f.max
+		if part != "*" {
+			start, end, isRange := strings.Cut(part, "-")
+			var err error
+			if from, err = f.value(start); err != nil {
+				return 0, err
+			}
+			to = from
+			if isRange {
+				if to, err = f.value(end); err != nil {
+					return 0, err
+				}
+			} else if step > 1 {
+				to = f.max
+			}
+			if to < from {
+				return 0, fmt.Errorf("invalid range %q", part)
+			}
+		}
+		for i := from; i <= to; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func (f field) value(value string) (int, error) {
+	for i, name := range f.names {
+		if value == name {
+			return i + f.namesFrom, nil
+		}
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < f.min || n > f.max {
+		return 0, fmt.Errorf("%q should be between %d and %d", value, f.min, f.max)
+	}
+	return n, nil
+}
+
+// Next returns the first occurrence strictly after t, in the location of t.
+// It returns the zero time when there is none in the next five years, e.g. "0 0 30 2 *".
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if s.month&(1<<uint(t.Month())) == 0 {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchesDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.hour&(1<<uint(t.Hour())) == 0 {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+			continue
+		}
+		if s.minute&(1<<uint(t.Minute())) == 0 {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domRestricted && s.dowRestricted {
+		return dom || dow
+	}
+	return dom && dow
+}
@@ -0,0 +1,129 @@
+package recurrence
+
+import (
+	"log"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/list"
+)
+
+// LiveLists are the lists being edited.
+type LiveLists interface {
+	// Reload drops the edited state of the list, showing the saved one to its editors.
+	Reload(listId int64)
+}
+
+// Scheduler starts the next occurrence of recurring lists when it is due.
+type Scheduler struct {
+	lists    list.ListsRepository
+	live     LiveLists
+	interval time.Duration
+}
+
+func NewScheduler(lists list.ListsRepository, live LiveLists, interval time.Duration) *Scheduler {
+	return &Scheduler{lists: lists, live: live, interval: interval}
+}
+
+func (s *Scheduler) Run() {
+	ticker := time.NewTicker(s.interval)
+	for {
+		s.RunOnce(time.Now())
+		<-ticker.C
+	}
+}
+
+func (s *Scheduler) RunOnce(now time.Time) {
+	ids, err := s.lists.FindOccurrencesDue(now)
+	if err != nil {
+		log.Println("Error finding recurring lists", err)
+		return
+	}
+	for _, id := range ids {
+		if err := s.occur(id, now); err != nil {
+			log.Println("Error starting next occurrence of list", id, err)
+		}
+	}
+}
+
+// occur archives a copy of the list as it was saved and starts the next occurrence,
+// unchecking the items of the list or continuing in a fresh copy. Unsaved changes
+// were made to the occurrence that ended, so its editors are shown the new one instead.
+func (s *Scheduler) occur(listId int64, now time.Time) error {
+	saved, err := s.lists.Get(listId)
+	if err != nil {
+		return err
+	}
+	current := &saved
+	schedule, err := Parse(current.Recurrence)
+	if err != nil {
+		return err
+	}
+	next := schedule.Next(now.Local()).UTC()
+	seriesId := current.Series()
+
+	if current.RecurrenceMode == list.RecurrenceSpawn {
+		fresh, err := s.copyList(current, list.CopyOptions{Uncheck: true, KeepAssignees: true})
+		if err != nil {
+			return err
+		}
+		fresh.Recurrence = current.Recurrence
+		fresh.RecurrenceMode = current.RecurrenceMode
+		fresh.NextOccurrenceAt = nextOrNil(next)
+		fresh.SeriesId = &seriesId
+		if _, err := s.lists.Update(fresh); err != nil {
+			return err
+		}
+		if err := s.lists.Archive(current.Id, now); err != nil {
+			return err
+		}
+		s.live.Reload(current.Id)
+		return nil
+	}
+
+	previous, err := s.copyList(current, list.CopyOptions{KeepAssignees: true})
+	if err != nil {
+		return err
+	}
+	previous.SeriesId = &seriesId
+	if _, err := s.lists.Update(previous); err != nil {
+		return err
+	}
+	if err := s.lists.Archive(previous.Id, now); err != nil {
+		return err
+	}
+	current.Groups = current.CopyGroups(list.CopyOptions{Uncheck: true, KeepAssignees: true})
+	current.NextOccurrenceAt = nextOrNil(next)
+	if _, err := s.lists.Update(current); err != nil {
+		return err
+	}
+	s.live.Reload(current.Id)
+	return nil
+}
+
+// copyList creates a new list with the contents and members of l.
+func (s *Scheduler) copyList(l *list.List, options list.CopyOptions) (*list.List, error) {
+	params := &list.ListCreationParams{Title: l.Title, Description: l.Description, CreatorId: l.Creator.Id}
+	if l.Community != nil {
+		params.CommunityId = &l.Community.CommunityId
+	}
+	created, err := s.lists.Create(params)
+	if err != nil {
+		return nil, err
+	}
+	created.Groups = l.CopyGroups(options)
+	created.Colaborators = l.Colaborators
+	created.ColaboratorRoles = l.ColaboratorRoles
+	created.Budget = l.Budget
+	created.Currency = l.Currency
+	created.DueAt = l.DueAt
+	created.Tags = l.Tags
+	return &created, nil
+}
+
+// nextOrNil stops the recurrence when the rule has no next occurrence.
+func nextOrNil(next time.Time) *time.Time {
+	if next.IsZero() {
+		return nil
+	}
+	return &next
+}
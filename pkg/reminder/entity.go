@@ -0,0 +1,24 @@
+package reminder
+
+import "time"
+
+// Preference is how a user wants to be reminded about a list.
+type Preference struct {
+	ListId       int64
+	UserId       int64
+	SnoozedUntil *time.Time
+	OptedOut     bool
+}
+
+// Mutes tells whether reminders should not be sent at the given time.
+func (p *Preference) Mutes(now time.Time) bool {
+	return p.OptedOut || p.IsSnoozed(now)
+}
+
+func (p *Preference) IsSnoozed(now time.Time) bool {
+	return p.SnoozedUntil != nil && now.Before(*p.SnoozedUntil)
+}
+
+func (p *Preference) Snoozed() bool {
+	return p.IsSnoozed(time.Now())
+}
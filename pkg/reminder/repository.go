@@ -0,0 +1,13 @@
+package reminder
+
+import "time"
+
+type RemindersRepository interface {
+	// FindListsDueAfter returns the ids of the lists with a due date after t.
+	FindListsDueAfter(t time.Time) ([]int64, error)
+	WasSent(listId int64, userId int64, offset time.Duration) (bool, error)
+	MarkSent(listId int64, userId int64, offset time.Duration, sentAt time.Time) error
+	// GetPreference returns the default preference when the user never changed it.
+	GetPreference(listId int64, userId int64) (Preference, error)
+	SavePreference(preference *Preference) error
+}
@@ -0,0 +1,145 @@
+package reminder
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/user"
+)
+
+type SendEmailFunc func(to []string, subject string, body string) error
+
+// Scheduler emails the members of lists with a due date about the items
+// still to buy, once for each offset before the due date.
+type Scheduler struct {
+	lists     list.ListsRepository
+	reminders RemindersRepository
+	sendEmail SendEmailFunc
+	// Sorted from the farthest to the closest to the due date
+	offsets  []time.Duration
+	interval time.Duration
+	appUrl   string
+}
+
+func NewScheduler(lists list.ListsRepository, reminders RemindersRepository, sendEmail SendEmailFunc, offsets []time.Duration, interval time.Duration, appUrl string) *Scheduler {
+	sorted := append([]time.Duration{}, offsets...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] > sorted[j] })
+	return &Scheduler{
+		lists:     lists,
+		reminders: reminders,
+		sendEmail: sendEmail,
+		offsets:   sorted,
+		interval:  interval,
+		appUrl:    appUrl,
+	}
+}
+
+func (s *Scheduler) Run() {
+	if len(s.offsets) == 0 {
+		return
+	}
+	ticker := time.NewTicker(s.interval)
+	for {
+		s.RunOnce(time.Now())
+		<-ticker.C
+	}
+}
+
+// RunOnce sends the reminders that are due at now.
+func (s *Scheduler) RunOnce(now time.Time) {
+	ids, err := s.reminders.FindListsDueAfter(now)
+	if err != nil {
+		log.Println("Error finding lists with due date", err)
+		return
+	}
+	for _, id := range ids {
+		l, err := s.lists.Get(id)
+		if err != nil {
+			log.Println("Error getting list for reminders", id, err)
+			continue
+		}
+		if l.DueAt == nil {
+			continue
+		}
+		offset, ok := s.currentOffset(now, *l.DueAt)
+		if !ok {
+			continue
+		}
+		for _, pending := range pendingByMember(&l) {
+			if err := s.remind(now, &l, offset, pending); err != nil {
+				log.Println("Error sending reminder", l.Id, pending.member.Id, err)
+			}
+		}
+	}
+}
+
+// currentOffset is the closest offset to the due date that was already reached.
+func (s *Scheduler) currentOffset(now time.Time, dueAt time.Time) (time.Duration, bool) {
+	left := dueAt.Sub(now)
+	for i := len(s.offsets) - 1; i >= 0; i-- {
+		if s.offsets[i] >= left {
+			return s.offsets[i], true
+		}
+	}
+	return 0, false
+}
+
+func (s *Scheduler) remind(now time.Time, l *list.List, offset time.Duration, pending memberItems) error {
+	sent, err := s.reminders.WasSent(l.Id, pending.member.Id, offset)
+	if err != nil || sent {
+		return err
+	}
+	preference, err := s.reminders.GetPreference(l.Id, pending.member.Id)
+	if err != nil {
+		return err
+	}
+	if preference.Mutes(now) {
+		return nil
+	}
+	if err := s.sendEmail([]string{pending.member.Email}, fmt.Sprintf("Reminder: %s is due %s", l.Title, l.DueAt.Local().Format("Mon Jan 2 15:04")), s.body(l, pending.items)); err != nil {
+		return err
+	}
+	return s.reminders.MarkSent(l.Id, pending.member.Id, offset, now)
+}
+
+func (s *Scheduler) body(l *list.List, items []*list.Item) string {
+	b := strings.Builder{}
+	fmt.Fprintf(&b, "The list %q is due at %s and these items are still unchecked:\n\n", l.Title, l.DueAt.Local().Format("Mon Jan 2 15:04"))
+	for _, item := range items {
+		fmt.Fprintf(&b, "- %s %s\n", item.QuantityWithUnit(), item.Description)
+	}
+	fmt.Fprintf(&b, "\nSee the list, snooze or stop these reminders at %s/lists/%d\n", s.appUrl, l.Id)
+	return b.String()
+}
+
+type memberItems struct {
+	member user.User
+	items  []*list.Item
+}
+
+// pendingByMember gives each member the unchecked items they are responsible for:
+// the ones assigned to them and the ones assigned to nobody.
+func pendingByMember(l *list.List) []memberItems {
+	pending := make([]memberItems, 0)
+	for _, member := range l.Members() {
+		items := make([]*list.Item, 0)
+		for _, group := range l.Groups {
+			for _, item := range group.Items {
+				if item.Checked != 0 {
+					continue
+				}
+				if item.AssigneeId == nil || *item.AssigneeId == member.Id {
+					items = append(items, item)
+				}
+			}
+		}
+		if len(items) > 0 && member.Email != "" {
+			pending = append(pending, memberItems{member: member, items: items})
+		}
+	}
+	return pending
+}
@@ -0,0 +1,96 @@
+package reminder
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlRemindersRepository struct{}
+
+// FindListsDueAfter implements RemindersRepository.
+func (s *SqlRemindersRepository) FindListsDueAfter(t time.Time) ([]int64, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// Due dates are stored in UTC, so they compare as text
+	rows, err := db.Query(`SELECT listId FROM list WHERE dueAt IS NOT NULL AND dueAt > ? AND deletedAt IS NULL`, t.UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	ids := make([]int64, 0)
+	for rows.Next() {
+		var id int64
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
+
+// WasSent implements RemindersRepository.
+func (s *SqlRemindersRepository) WasSent(listId int64, userId int64, offset time.Duration) (bool, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return false, err
+	}
+	defer db.Close()
+	var count int
+	err = db.QueryRow(`
+    SELECT COUNT(*) FROM list_reminder_sent
+    WHERE listId = ? AND luserId = ? AND offsetSeconds = ?
+  `, listId, userId, int64(offset.Seconds())).Scan(&count)
+	return count > 0, err
+}
+
+// MarkSent implements RemindersRepository.
+func (s *SqlRemindersRepository) MarkSent(listId int64, userId int64, offset time.Duration, sentAt time.Time) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+    INSERT OR REPLACE INTO list_reminder_sent (listId, luserId, offsetSeconds, sentAt)
+    VALUES (?, ?, ?, ?)
+  `, listId, userId, int64(offset.Seconds()), sentAt)
+	return err
+}
+
+// GetPreference implements RemindersRepository.
+func (s *SqlRemindersRepository) GetPreference(listId int64, userId int64) (Preference, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Preference{}, err
+	}
+	defer db.Close()
+	p := Preference{ListId: listId, UserId: userId}
+	err = db.QueryRow(`
+    SELECT snoozedUntil, optedOut FROM list_reminder_preference
+    WHERE listId = ? AND luserId = ?
+  `, listId, userId).Scan(&p.SnoozedUntil, &p.OptedOut)
+	if errors.Is(err, sql.ErrNoRows) {
+		return p, nil
+	}
+	return p, err
+}
+
+// SavePreference implements RemindersRepository.
+func (s *SqlRemindersRepository) SavePreference(p *Preference) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	_, err = db.Exec(`
+    INSERT OR REPLACE INTO list_reminder_preference (listId, luserId, snoozedUntil, optedOut)
+    VALUES (?, ?, ?, ?)
+  `, p.ListId, p.UserId, p.SnoozedUntil, p.OptedOut)
+	return err
+}
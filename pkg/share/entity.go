@@ -0,0 +1,52 @@
+// Package share gives lists links that show them to people without an account.
+package share
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/user"
+)
+
+// Share is a link to a read-only view of a list, following its changes live.
+type Share struct {
+	Id     int64
+	ListId int64
+	// Secret part of the link
+	Token string
+	// Whoever has the link can also check items off
+	CanCheck bool
+	// When the link stops working, in UTC, nil when it never does
+	ExpiresAt *time.Time
+	RevokedAt *time.Time
+	CreatorId int64
+	CreatedAt time.Time
+}
+
+var ErrNotFound = errors.New("share link not found")
+
+// NewToken returns a random token for a new link.
+func NewToken() string {
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// IsActive tells whether the link still shows the list at the given time.
+func (s *Share) IsActive(now time.Time) bool {
+	return s.RevokedAt == nil && (s.ExpiresAt == nil || now.Before(*s.ExpiresAt))
+}
+
+func (s Share) Path() string {
+	return "/shared/" + s.Token
+}
+
+// Guest is who is following the list through the link. Their id is negative, not to
+// be taken for a user's, and their purchases are recorded as the ones of the creator.
+func (s *Share) Guest() *user.User {
+	return &user.User{Id: -s.Id, Username: "Guest"}
+}
@@ -0,0 +1,12 @@
+package share
+
+type SharesRepository interface {
+	Create(share *Share) error
+	// FindByToken returns the share of the token, even if it is no longer active, or ErrNotFound
+	// when there is none or its list is gone or in the trash.
+	FindByToken(token string) (Share, error)
+	// FindActive returns the links of the list that still work, the newest first.
+	FindActive(listId int64) ([]Share, error)
+	// Revoke stops the link of the list from working.
+	Revoke(listId int64, shareId int64) error
+}
@@ -0,0 +1,110 @@
+package share
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+type SqlSharesRepository struct{}
+
+const shareColumns = `shareId, listId, token, canCheck, expiresAt, revokedAt, creatorLuserId, createdAt`
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanShare(row scanner) (Share, error) {
+	s := Share{}
+	err := row.Scan(&s.Id, &s.ListId, &s.Token, &s.CanCheck, &s.ExpiresAt, &s.RevokedAt, &s.CreatorId, &s.CreatedAt)
+	return s, err
+}
+
+// Create implements SharesRepository.
+func (s *SqlSharesRepository) Create(share *Share) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	share.Token = NewToken()
+	share.CreatedAt = time.Now().UTC()
+	result, err := db.Exec(`
+    INSERT INTO list_share (listId, token, canCheck, expiresAt, creatorLuserId, createdAt)
+    VALUES (?, ?, ?, ?, ?, ?)
+  `, share.ListId, share.Token, share.CanCheck, share.ExpiresAt, share.CreatorId, share.CreatedAt)
+	if err != nil {
+		return err
+	}
+	share.Id, err = result.LastInsertId()
+	return err
+}
+
+// FindByToken implements SharesRepository.
+func (s *SqlSharesRepository) FindByToken(token string) (Share, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return Share{}, err
+	}
+	defer db.Close()
+	found, err := scanShare(db.QueryRow(`
+    SELECT `+shareColumns+`
+    FROM list_share
+    WHERE token = ? AND listId IN (SELECT listId FROM list WHERE deletedAt IS NULL)
+  `, token))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Share{}, ErrNotFound
+	}
+	return found, err
+}
+
+// FindActive implements SharesRepository.
+func (s *SqlSharesRepository) FindActive(listId int64) ([]Share, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	// Expiry dates are stored in UTC, so they compare as text
+	rows, err := db.Query(`
+    SELECT `+shareColumns+`
+    FROM list_share
+    WHERE listId = ? AND revokedAt IS NULL AND (expiresAt IS NULL OR expiresAt > ?)
+    ORDER BY createdAt DESC, shareId DESC
+  `, listId, time.Now().UTC())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	shares := make([]Share, 0)
+	for rows.Next() {
+		found, err := scanShare(rows)
+		if err != nil {
+			return nil, err
+		}
+		shares = append(shares, found)
+	}
+	return shares, rows.Err()
+}
+
+// Revoke implements SharesRepository.
+func (s *SqlSharesRepository) Revoke(listId int64, shareId int64) error {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	result, err := db.Exec(`UPDATE list_share SET revokedAt = ? WHERE shareId = ? AND listId = ? AND revokedAt IS NULL`,
+		time.Now().UTC(), shareId, listId)
+	if err != nil {
+		return err
+	}
+	if affected, err := result.RowsAffected(); err != nil {
+		return err
+	} else if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,41 @@
+package trash
+
+import (
+	"log"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/attachment"
+	"vilmasoftware.com/colablists/pkg/list"
+)
+
+// Scheduler purges the lists and groups that stayed in the trash for longer than the retention.
+type Scheduler struct {
+	lists       list.ListsRepository
+	attachments *attachment.Service
+	retention   time.Duration
+	interval    time.Duration
+}
+
+func NewScheduler(lists list.ListsRepository, attachments *attachment.Service, retention time.Duration, interval time.Duration) *Scheduler {
+	return &Scheduler{lists: lists, attachments: attachments, retention: retention, interval: interval}
+}
+
+func (s *Scheduler) Run() {
+	ticker := time.NewTicker(s.interval)
+	for {
+		s.RunOnce(time.Now())
+		<-ticker.C
+	}
+}
+
+func (s *Scheduler) RunOnce(now time.Time) {
+	purged, files, err := s.lists.PurgeTrash(now.Add(-s.retention))
+	if err != nil {
+		log.Println("Error purging the trash", err)
+		return
+	}
+	s.attachments.DeleteFiles(files...)
+	if purged > 0 {
+		log.Printf("Purged %d lists and groups from the trash\n", purged)
+	}
+}
@@ -0,0 +1,35 @@
+package trip
+
+import (
+	"fmt"
+	"time"
+
+	"vilmasoftware.com/colablists/pkg/user"
+)
+
+// Trip is the summary of a finished shopping trip.
+type Trip struct {
+	Id           int64
+	ListId       int64
+	ListTitle    string
+	CommunityId  *int64
+	Shopper      user.User
+	StartedAt    time.Time
+	EndedAt      time.Time
+	ItemsBought  int
+	ItemsMissing int
+	MissingItems []string
+}
+
+func (t *Trip) Duration() time.Duration {
+	return t.EndedAt.Sub(t.StartedAt)
+}
+
+// DurationText formats the duration as "1h05m" or "12m".
+func (t *Trip) DurationText() string {
+	d := t.Duration().Round(time.Minute)
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	}
+	return fmt.Sprintf("%dh%02dm", int(d.Hours()), int(d.Minutes())%60)
+}
@@ -0,0 +1,7 @@
+package trip
+
+type TripsRepository interface {
+	Save(trip *Trip) (*Trip, error)
+	FindByList(listId int64) ([]Trip, error)
+	FindByCommunity(communityId int64) ([]Trip, error)
+}
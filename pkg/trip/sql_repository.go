@@ -0,0 +1,80 @@
+package trip
+
+import (
+	"strings"
+
+	"vilmasoftware.com/colablists/pkg/infra"
+)
+
+// Number of trips returned by the Find methods, most recent first.
+const tripsPageSize = 20
+
+type SqlTripsRepository struct{}
+
+// Save implements TripsRepository.
+func (s *SqlTripsRepository) Save(trip *Trip) (*Trip, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	result, err := db.Exec(`
+    INSERT INTO shopping_trip (listId, communityId, shopperLuserId, startedAt, endedAt, itemsBought, itemsMissing, missingItems)
+    VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+  `, trip.ListId, trip.CommunityId, trip.Shopper.Id, trip.StartedAt, trip.EndedAt, trip.ItemsBought, trip.ItemsMissing, strings.Join(trip.MissingItems, "\n"))
+	if err != nil {
+		return nil, err
+	}
+	trip.Id, err = result.LastInsertId()
+	if err != nil {
+		return nil, err
+	}
+	return trip, nil
+}
+
+// FindByList implements TripsRepository.
+func (s *SqlTripsRepository) FindByList(listId int64) ([]Trip, error) {
+	return s.find(`WHERE t.listId = ?`, listId)
+}
+
+// FindByCommunity implements TripsRepository.
+func (s *SqlTripsRepository) FindByCommunity(communityId int64) ([]Trip, error) {
+	return s.find(`WHERE t.communityId = ?`, communityId)
+}
+
+func (s *SqlTripsRepository) find(where string, args ...interface{}) ([]Trip, error) {
+	db, err := infra.CreateConnection()
+	if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+	rows, err := db.Query(`
+    SELECT t.tripId, t.listId, COALESCE(l.title, ''), t.communityId, t.shopperLuserId, COALESCE(u.username, ''), COALESCE(u.avatarUrl, ''),
+      t.startedAt, t.endedAt, t.itemsBought, t.itemsMissing, t.missingItems
+    FROM shopping_trip t
+    LEFT JOIN list l ON l.listId = t.listId
+    LEFT JOIN luser u ON u.luserId = t.shopperLuserId
+    `+where+`
+    ORDER BY t.endedAt DESC
+    LIMIT ?
+  `, append(args, tripsPageSize)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	trips := make([]Trip, 0)
+	for rows.Next() {
+		t := Trip{}
+		var missingItems string
+		err := rows.Scan(&t.Id, &t.ListId, &t.ListTitle, &t.CommunityId, &t.Shopper.Id, &t.Shopper.Username, &t.Shopper.AvatarUrl,
+			&t.StartedAt, &t.EndedAt, &t.ItemsBought, &t.ItemsMissing, &missingItems)
+		if err != nil {
+			return nil, err
+		}
+		if missingItems != "" {
+			t.MissingItems = strings.Split(missingItems, "\n")
+		}
+		trips = append(trips, t)
+	}
+	return trips, nil
+}
@@ -0,0 +1,38 @@
+package units
+
+// Unit names in English and Portuguese mapped to their symbol.
+var aliases = map[string]string{
+	// mass
+	"kg": "kg", "kgs": "kg", "kilo": "kg", "kilos": "kg", "kilogram": "kg", "kilograms": "kg",
+	"kilogramme": "kg", "kilogrammes": "kg", "quilo": "kg", "quilos": "kg", "quilograma": "kg", "quilogramas": "kg",
+	"mg": "mg", "milligram": "mg", "milligrams": "mg", "miligrama": "mg", "miligramas": "mg",
+	"g": "g", "gr": "g", "grs": "g", "gram": "g", "grams": "g", "gramme": "g", "grammes": "g", "grama": "g", "gramas": "g",
+	"lb": "lb", "lbs": "lb", "pound": "lb", "pounds": "lb", "libra": "lb", "libras": "lb",
+	"oz": "oz", "ounce": "oz", "ounces": "oz", "onça": "oz", "onças": "oz",
+	// volume
+	"l": "l", "lt": "l", "lts": "l", "liter": "l", "liters": "l", "litre": "l", "litres": "l", "litro": "l", "litros": "l",
+	"ml": "ml", "milliliter": "ml", "milliliters": "ml", "millilitre": "ml", "millilitres": "ml", "mililitro": "ml", "mililitros": "ml",
+	"gal": "gal", "gallon": "gal", "gallons": "gal", "galão": "gal", "galao": "gal", "galões": "gal", "galoes": "gal",
+	"cup": "cup", "cups": "cup", "xícara": "cup", "xícaras": "cup", "xicara": "cup", "xicaras": "cup",
+	"tbsp": "tbsp", "tablespoon": "tbsp", "tablespoons": "tbsp",
+	"floz": "floz",
+	"tsp":  "tsp", "teaspoon": "tsp", "teaspoons": "tsp",
+	// count and packaging
+	"pack": "pack", "packs": "pack", "packet": "pack", "packets": "pack", "pkg": "pack",
+	"pacote": "pack", "pacotes": "pack", "pct": "pack", "pcts": "pack",
+	"can": "can", "cans": "can", "tin": "can", "tins": "can", "lata": "can", "latas": "can",
+	"bottle": "bottle", "bottles": "bottle", "garrafa": "bottle", "garrafas": "bottle",
+	"box": "box", "boxes": "box", "caixa": "box", "caixas": "box", "cx": "box",
+	"bag": "bag", "bags": "bag", "saco": "bag", "sacos": "bag", "sacola": "bag", "sacolas": "bag",
+	"bunch": "bunch", "bunches": "bunch", "maço": "bunch", "maços": "bunch", "maco": "bunch", "macos": "bunch",
+	"jar": "jar", "jars": "jar", "pote": "jar", "potes": "jar",
+	"loaf": "loaf", "loaves": "loaf",
+	"unit": "unit", "units": "unit", "unidade": "unit", "unidades": "unit", "un": "unit", "und": "unit", "unid": "unit",
+	"pc": "unit", "pcs": "unit", "piece": "unit", "pieces": "unit",
+}
+
+// Lookup returns the symbol of a unit written in English or Portuguese, e.g. "kilos" or "litros".
+func Lookup(name string) (string, bool) {
+	symbol, ok := aliases[name]
+	return symbol, ok
+}
@@ -0,0 +1,101 @@
+package units
+
+type Quantity struct {
+	Amount float64
+	Unit   string
+}
+
+func (q Quantity) String() string {
+	unit, ok := Get(q.Unit)
+	if !ok {
+		return FormatAmount(q.Amount) + " " + q.Unit
+	}
+	if unit.System != "count" {
+		return FormatAmount(q.Amount) + " " + unit.Symbol
+	}
+	if q.Amount == 1 {
+		return FormatAmount(q.Amount) + " " + unit.Name
+	}
+	return FormatAmount(q.Amount) + " " + unit.Plural
+}
+
+// Aggregate sums the quantities that can be converted into each other, returning
+// one quantity per dimension in the order they first appear. Quantities that
+// all share a unit keep it, otherwise the sum is written in a metric unit.
+func Aggregate(quantities []Quantity) []Quantity {
+	type total struct {
+		base  float64
+		unit  string
+		mixed bool
+	}
+	totals := make(map[string]*total)
+	order := make([]string, 0)
+	unknown := make([]Quantity, 0)
+	for _, q := range quantities {
+		unit, ok := Get(q.Unit)
+		if !ok {
+			unknown = append(unknown, q)
+			continue
+		}
+		t, ok := totals[unit.Dimension]
+		if !ok {
+			t = &total{unit: unit.Symbol}
+			totals[unit.Dimension] = t
+			order = append(order, unit.Dimension)
+		}
+		if t.unit != unit.Symbol {
+			t.mixed = true
+		}
+		t.base += q.Amount * unit.ToBase
+	}
+	result := make([]Quantity, 0, len(order)+len(unknown))
+	for _, dimension := range order {
+		t := totals[dimension]
+		unit := t.unit
+		if t.mixed {
+			unit = metricUnitFor(dimension, t.base)
+		}
+		amount, _ := Convert(t.base, baseUnitOf(dimension), unit)
+		result = append(result, Quantity{Amount: amount, Unit: unit})
+	}
+	return append(result, unknown...)
+}
+
+// Sum adds up the quantities in the given unit. It fails when one of them can't be converted to it.
+func Sum(unit string, quantities ...Quantity) (Quantity, bool) {
+	total := Quantity{Unit: unit}
+	for _, q := range quantities {
+		amount, err := Convert(q.Amount, q.Unit, unit)
+		if err != nil {
+			return Quantity{}, false
+		}
+		total.Amount += amount
+	}
+	total.Amount = Round(total.Amount)
+	return total, true
+}
+
+func baseUnitOf(dimension string) string {
+	for _, unit := range Catalogue {
+		if unit.Dimension == dimension && unit.ToBase == 1 {
+			return unit.Symbol
+		}
+	}
+	return DefaultUnit
+}
+
+func metricUnitFor(dimension string, base float64) string {
+	switch dimension {
+	case Mass:
+		if base >= 1000 {
+			return "kg"
+		}
+		return "g"
+	case Volume:
+		if base >= 1000 {
+			return "l"
+		}
+		return "ml"
+	}
+	return baseUnitOf(dimension)
+}
@@ -0,0 +1,131 @@
+// Package units is the catalogue of units of measure items can be written in,
+// with conversion between compatible units and aggregation of quantities.
+package units
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+const (
+	Mass   = "mass"
+	Volume = "volume"
+	Count  = "count"
+)
+
+type Unit struct {
+	Symbol string
+	Name   string
+	Plural string
+	// Units can only be converted to others of the same dimension. Packaging
+	// units (pack, can, ...) are dimensions of their own since their size is unknown.
+	Dimension string
+	// metric, imperial or count
+	System string
+	// How many base units (g, ml or unit) one of this unit is
+	ToBase float64
+}
+
+// The unit used when an item has none.
+const DefaultUnit = "unit"
+
+var Catalogue = []Unit{
+	{Symbol: "unit", Name: "unit", Plural: "units", Dimension: Count, System: "count", ToBase: 1},
+	{Symbol: "dozen", Name: "dozen", Plural: "dozen", Dimension: Count, System: "count", ToBase: 12},
+	{Symbol: "pack", Name: "pack", Plural: "packs", Dimension: "pack", System: "count", ToBase: 1},
+	{Symbol: "can", Name: "can", Plural: "cans", Dimension: "can", System: "count", ToBase: 1},
+	{Symbol: "bottle", Name: "bottle", Plural: "bottles", Dimension: "bottle", System: "count", ToBase: 1},
+	{Symbol: "box", Name: "box", Plural: "boxes", Dimension: "box", System: "count", ToBase: 1},
+	{Symbol: "bag", Name: "bag", Plural: "bags", Dimension: "bag", System: "count", ToBase: 1},
+	{Symbol: "bunch", Name: "bunch", Plural: "bunches", Dimension: "bunch", System: "count", ToBase: 1},
+	{Symbol: "jar", Name: "jar", Plural: "jars", Dimension: "jar", System: "count", ToBase: 1},
+	{Symbol: "loaf", Name: "loaf", Plural: "loaves", Dimension: "loaf", System: "count", ToBase: 1},
+	{Symbol: "mg", Name: "milligram", Plural: "milligrams", Dimension: Mass, System: "metric", ToBase: 0.001},
+	{Symbol: "g", Name: "gram", Plural: "grams", Dimension: Mass, System: "metric", ToBase: 1},
+	{Symbol: "kg", Name: "kilogram", Plural: "kilograms", Dimension: Mass, System: "metric", ToBase: 1000},
+	{Symbol: "oz", Name: "ounce", Plural: "ounces", Dimension: Mass, System: "imperial", ToBase: 28.349523125},
+	{Symbol: "lb", Name: "pound", Plural: "pounds", Dimension: Mass, System: "imperial", ToBase: 453.59237},
+	{Symbol: "ml", Name: "millilitre", Plural: "millilitres", Dimension: Volume, System: "metric", ToBase: 1},
+	{Symbol: "l", Name: "litre", Plural: "litres", Dimension: Volume, System: "metric", ToBase: 1000},
+	{Symbol: "tsp", Name: "teaspoon", Plural: "teaspoons", Dimension: Volume, System: "imperial", ToBase: 4.92892159375},
+	{Symbol: "tbsp", Name: "tablespoon", Plural: "tablespoons", Dimension: Volume, System: "imperial", ToBase: 14.78676478125},
+	{Symbol: "floz", Name: "fluid ounce", Plural: "fluid ounces", Dimension: Volume, System: "imperial", ToBase: 29.5735295625},
+	{Symbol: "cup", Name: "cup", Plural: "cups", Dimension: Volume, System: "imperial", ToBase: 236.5882365},
+	{Symbol: "gal", Name: "gallon", Plural: "gallons", Dimension: Volume, System: "imperial", ToBase: 3785.411784},
+}
+
+var bySymbol = func() map[string]Unit {
+	result := make(map[string]Unit, len(Catalogue))
+	for _, unit := range Catalogue {
+		result[unit.Symbol] = unit
+	}
+	return result
+}()
+
+// Get returns the unit with the symbol. An empty symbol is the default unit.
+func Get(symbol string) (Unit, bool) {
+	if symbol == "" {
+		symbol = DefaultUnit
+	}
+	unit, ok := bySymbol[symbol]
+	return unit, ok
+}
+
+// Normalize validates a unit symbol, also accepting aliases such as "kilos" or "litros".
+func Normalize(symbol string) (string, bool) {
+	if symbol == "" {
+		return "", true
+	}
+	if _, ok := bySymbol[symbol]; ok {
+		return symbol, true
+	}
+	return Lookup(strings.ToLower(symbol))
+}
+
+func Compatible(from, to string) bool {
+	fromUnit, ok := Get(from)
+	if !ok {
+		return false
+	}
+	toUnit, ok := Get(to)
+	return ok && fromUnit.Dimension == toUnit.Dimension
+}
+
+func Convert(amount float64, from, to string) (float64, error) {
+	fromUnit, ok := Get(from)
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", from)
+	}
+	toUnit, ok := Get(to)
+	if !ok {
+		return 0, fmt.Errorf("unknown unit %q", to)
+	}
+	if fromUnit.Dimension != toUnit.Dimension {
+		return 0, fmt.Errorf("cannot convert %s to %s", fromUnit.Plural, toUnit.Plural)
+	}
+	return Round(amount * fromUnit.ToBase / toUnit.ToBase), nil
+}
+
+// Round keeps three decimal places, enough for any quantity on a list.
+func Round(amount float64) float64 {
+	return math.Round(amount*1000) / 1000
+}
+
+// FormatAmount prints amounts without trailing zeros, e.g. "2", "1.5".
+func FormatAmount(amount float64) string {
+	return strconv.FormatFloat(Round(amount), 'f', -1, 64)
+}
+
+// ParseAmount accepts positive decimal amounts written with a dot or a comma, e.g. "1,5".
+func ParseAmount(value string) (float64, error) {
+	amount, err := strconv.ParseFloat(strings.Replace(strings.TrimSpace(value), ",", ".", 1), 64)
+	if err != nil {
+		return 0, err
+	}
+	if amount < 0 || math.IsInf(amount, 0) || math.IsNaN(amount) {
+		return 0, fmt.Errorf("invalid quantity %q", value)
+	}
+	return Round(amount), nil
+}
@@ -0,0 +1,76 @@
+package user
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Palette used to give collaborators distinct colors when they didn't pick one.
+var ColorPalette = []string{
+	"#18d825",
+	"#1e88e5",
+	"#e53935",
+	"#8e24aa",
+	"#fb8c00",
+	"#00897b",
+	"#d81b60",
+	"#6d4c41",
+	"#3949ab",
+	"#c0ca33",
+}
+
+var hexColorRegex = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+// NormalizeColor validates a #rrggbb color and returns it lower-cased.
+func NormalizeColor(color string) (string, bool) {
+	color = strings.TrimSpace(color)
+	if !hexColorRegex.MatchString(color) {
+		return "", false
+	}
+	return strings.ToLower(color), true
+}
+
+func defaultColorIndex(userId int64) int {
+	if userId < 0 {
+		userId = -userId
+	}
+	return int(userId % int64(len(ColorPalette)))
+}
+
+// DefaultColor is the deterministic color of a user without a saved preference.
+func DefaultColor(userId int64) string {
+	return ColorPalette[defaultColorIndex(userId)]
+}
+
+// PreferredColor returns the saved color of the user or its default.
+func (user *User) PreferredColor() string {
+	if color, ok := NormalizeColor(user.Color); ok {
+		return color
+	}
+	return DefaultColor(user.Id)
+}
+
+// PickColor returns the preferred color of the user unless it is already taken,
+// in which case the next free palette color (starting from the user default) is used.
+func (user *User) PickColor(taken []string) string {
+	isTaken := func(color string) bool {
+		for _, t := range taken {
+			if strings.EqualFold(t, color) {
+				return true
+			}
+		}
+		return false
+	}
+	preferred := user.PreferredColor()
+	if !isTaken(preferred) {
+		return preferred
+	}
+	start := defaultColorIndex(user.Id)
+	for i := 0; i < len(ColorPalette); i++ {
+		color := ColorPalette[(start+i)%len(ColorPalette)]
+		if !isTaken(color) {
+			return color
+		}
+	}
+	return preferred
+}
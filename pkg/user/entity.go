@@ -10,6 +10,7 @@ type User struct {
 	Online       bool   `json:"online"`
 	Email        string `json:"email"`
 	AvatarUrl    string `json:"avatarUrl"`
+	Color        string `json:"color"`
 }
 
 func (user *User) String() string {
@@ -8,4 +8,5 @@ type UsersRepository interface {
 	UnsafeGetByUsername(username string) (*User, error)
 	ComparePassword(password []byte, hashedPasswowrd []byte) bool
 	FindByEmail(email string) (User, error)
+	UpdateColor(userId int64, color string) error
 }
@@ -2,7 +2,7 @@ package user
 
 func UnsafeScanUser(row Scanner) (User, error) {
 	user := &User{}
-	err := row.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.PasswordSalt, &user.Email, &user.AvatarUrl)
+	err := row.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.PasswordSalt, &user.Email, &user.AvatarUrl, &user.Color)
 	if err != nil {
 		return User{}, err
 	}
@@ -10,7 +10,7 @@ func UnsafeScanUser(row Scanner) (User, error) {
 }
 
 func ScanUser(row Scanner, user *User) error {
-	err := row.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.PasswordSalt, &user.Email, &user.AvatarUrl)
+	err := row.Scan(&user.Id, &user.Username, &user.PasswordHash, &user.PasswordSalt, &user.Email, &user.AvatarUrl, &user.Color)
 	if err != nil {
 		return err
 	}
@@ -194,3 +194,17 @@ func (s *SqlUsersRepository) FindByEmail(email string) (User, error) {
 	}
 	return user, nil
 }
+
+func (s *SqlUsersRepository) UpdateColor(userId int64, color string) error {
+	normalized, ok := NormalizeColor(color)
+	if !ok {
+		return fmt.Errorf("invalid color %q, expected #rrggbb", color)
+	}
+	conn, err := infra.CreateConnection()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, err = conn.Exec(`UPDATE luser SET color = ? WHERE luserId = ?`, normalized, userId)
+	return err
+}
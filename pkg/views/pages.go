@@ -6,7 +6,17 @@ import (
 	textTemplate "text/template"
 
 	"vilmasoftware.com/colablists/pkg/community"
+	"vilmasoftware.com/colablists/pkg/delivery"
 	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/mealplan"
+	"vilmasoftware.com/colablists/pkg/nutrition"
+	"vilmasoftware.com/colablists/pkg/pantry"
+	"vilmasoftware.com/colablists/pkg/purchase"
+	"vilmasoftware.com/colablists/pkg/recipe"
+	"vilmasoftware.com/colablists/pkg/reminder"
+	"vilmasoftware.com/colablists/pkg/share"
+	"vilmasoftware.com/colablists/pkg/trip"
+	"vilmasoftware.com/colablists/pkg/units"
 	"vilmasoftware.com/colablists/pkg/user"
 )
 
@@ -19,27 +29,126 @@ type ListArgs struct {
 	Editing  bool
 	AllUsers []user.User
 	IsDirty  bool
+	Trips    []trip.Trip
+	// Reminder preference of the logged user
+	Reminders *reminder.Preference
+	// Previous occurrences of a recurring list
+	Occurrences []list.List
+	// Communities the list can be shared with as a template
+	Communities []*community.Community
+	// A delivery provider is set up, so the list can be ordered
+	Delivery bool
+	// Role of the logged user in the list
+	Role list.Role
+	// Links that show the list to people without an account, for its editors
+	Shares []share.Share
 }
 
 func (t *templates) RenderList(w io.Writer, args *ListArgs) {
 	t.renderBase(w, &baseArgs{ExtraHead: t.ExecuteTemplateString(t.List, "extrahead", args), Body: t.ExecuteTemplateString(t.List, "body", args), Title: "!!!!" + args.List.Title, Description: GetDescription("")})
 }
 
+type SharedListArgs struct {
+	List  ListUi
+	Share share.Share
+}
+
+// RenderSharedList renders the read-only view of a list opened through a share link.
+func (t *templates) RenderSharedList(w io.Writer, args *SharedListArgs) {
+	t.renderBase(w, &baseArgs{ExtraHead: t.ExecuteTemplateString(t.List, "extrahead", args), Body: t.ExecuteTemplateString(t.List, "bodyshared", args), Title: args.List.Title, Description: GetDescription("")})
+}
+
 type ListCreationForm struct {
 	Communities      []*community.Community
 	DefaultCommunity *community.Community
+	Templates        []list.List
+	// Template selected to start from, zero for an empty list
+	TemplateId int64
 }
 
 type ListsArgs struct {
-	Lists []list.List
-	Form  ListCreationForm
-	New   bool
+	Lists     []list.List
+	Filters   ListsFilters
+	Templates []list.List
+	Form      ListCreationForm
+	New       bool
+}
+
+// ListsFilters are the filters applied to the lists page and the options to change them.
+type ListsFilters struct {
+	Query list.ListsQuery
+	// Tags of the lists the user can see
+	Tags   []string
+	Sorts  []string
+	UserId int64
+	// Empty on the last page
+	NextPage string
+}
+
+// IsCommunity tells whether the lists are filtered by the community.
+func (f ListsFilters) IsCommunity(communityId int64) bool {
+	return f.Query.CommunityId != nil && *f.Query.CommunityId == communityId
+}
+
+// ByMe tells whether the lists are filtered by the ones the user created.
+func (f ListsFilters) ByMe() bool {
+	return f.Query.CreatorId != nil && *f.Query.CreatorId == f.UserId
 }
 
 func (t *templates) RenderLists(w io.Writer, args *ListsArgs) {
 	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "body", args), Title: "your marketlists", Description: GetDescription("")})
 }
 
+type MyItemsArgs struct {
+	Items []list.AssignedItem
+}
+
+func (t *templates) RenderMyItems(w io.Writer, args *MyItemsArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodymyitems", args), Title: "My items", Description: GetDescription("")})
+}
+
+type SearchArgs struct {
+	Query   string
+	Results []list.SearchResult
+}
+
+func (t *templates) RenderSearch(w io.Writer, args *SearchArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodysearch", args), Title: "Search", Description: GetDescription("")})
+}
+
+type MergeArgs struct {
+	Target list.List
+	// Lists of the user that can be merged into the target
+	Sources []list.List
+	// Nil until a source is chosen
+	Plan *list.MergePlan
+}
+
+func (t *templates) RenderMerge(w io.Writer, args *MergeArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodymerge", args), Title: "Merge into " + args.Target.Title, Description: GetDescription("")})
+}
+
+type PurchasesArgs struct {
+	// Nil for the purchases of the user
+	Community *community.Community
+	// The most bought items first
+	Stats  []purchase.ItemStats
+	Recent []purchase.Purchase
+}
+
+func (t *templates) RenderPurchases(w io.Writer, args *PurchasesArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodypurchases", args), Title: "Purchases", Description: GetDescription("")})
+}
+
+type TrashArgs struct {
+	Trash         list.Trash
+	RetentionDays int
+}
+
+func (t *templates) RenderTrash(w io.Writer, args *TrashArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodytrash", args), Title: "Trash", Description: GetDescription("")})
+}
+
 func (t *templates) RenderLogin(w io.Writer, args *SignupArgs) {
 	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Auth, "bodylogin", args), Title: "Login", Description: GetDescription("")})
 }
@@ -89,6 +198,7 @@ type CommunitiesArgs struct {
 	Query             CommunitiesQuery
 	Communities       []*community.Community
 	SelectedCommunity *community.Community
+	Trips             []trip.Trip
 }
 
 func (t *templates) RenderCommunities(w io.Writer, args *CommunitiesArgs) {
@@ -100,6 +210,136 @@ func (t *templates) RenderCommunities(w io.Writer, args *CommunitiesArgs) {
 	})
 }
 
+type PantryArgs struct {
+	Community *community.Community
+	Pantry    pantry.Pantry
+	// Lists of the community that can be designated as the shopping list
+	Lists []list.List
+	Units []units.Unit
+}
+
+func (t *templates) RenderPantry(w io.Writer, args *PantryArgs) {
+	t.renderBase(w, &baseArgs{
+		Title:       "Pantry of " + args.Community.CommunityName,
+		Description: GetDescription(""),
+		Body:        t.ExecuteTemplateString(t.Communities, "pantrybody", args),
+	})
+}
+
+type RecipesArgs struct {
+	Recipes []recipe.Recipe
+	// Empty, filled in by the form of a new recipe
+	Recipe recipe.Recipe
+	// Communities a new recipe can be shared with
+	Communities []*community.Community
+}
+
+func (t *templates) RenderRecipes(w io.Writer, args *RecipesArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Recipes, "bodyrecipes", args), Title: "Recipes", Description: GetDescription("")})
+}
+
+type RecipeArgs struct {
+	Recipe recipe.Recipe
+	// Only the creator changes the recipe
+	CanEdit     bool
+	Communities []*community.Community
+	// Lists the ingredients can be added to
+	Lists []list.List
+}
+
+func (t *templates) RenderRecipe(w io.Writer, args *RecipeArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Recipes, "bodyrecipe", args), Title: args.Recipe.Title, Description: GetDescription("")})
+}
+
+type MealPlanArgs struct {
+	Community *community.Community
+	Week      *mealplan.Week
+	// Recipes shared with the community that can be planned
+	Recipes []recipe.Recipe
+	// Lists of the community the ingredients of the week can be put on
+	Lists []list.List
+	// Of the ingredients of the week
+	Nutrition *NutritionEstimate
+	Target    nutrition.Target
+}
+
+func (t *templates) RenderMealPlan(w io.Writer, args *MealPlanArgs) {
+	t.renderBase(w, &baseArgs{
+		Title:       "Meal plan of " + args.Community.CommunityName,
+		Description: GetDescription(""),
+		Body:        t.ExecuteTemplateString(t.Recipes, "bodymealplan", args),
+	})
+}
+
+// NutritionEstimate is an estimate compared to the targets of a community for a number of days.
+type NutritionEstimate struct {
+	Estimate *nutrition.Estimate
+	// Empty when the community has no targets
+	Comparisons []nutrition.Comparison
+	Days        int
+}
+
+type NutritionArgs struct {
+	List      *list.List
+	Nutrition *NutritionEstimate
+}
+
+func (t *templates) RenderNutrition(w io.Writer, args *NutritionArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodynutrition", args), Title: "Nutrition of " + args.List.Title, Description: GetDescription("")})
+}
+
+type DeliveryArgs struct {
+	List     *list.List
+	Provider string
+	// Items the provider sells
+	Lines []delivery.OrderLine
+	// Items the provider does not sell
+	Unmatched []delivery.OrderLine
+	Total     float64
+	Currency  string
+}
+
+func NewDeliveryArgs(l *list.List, provider string, lines []delivery.OrderLine) *DeliveryArgs {
+	args := &DeliveryArgs{List: l, Provider: provider}
+	for _, line := range lines {
+		if line.Product == nil {
+			args.Unmatched = append(args.Unmatched, line)
+			continue
+		}
+		args.Lines = append(args.Lines, line)
+		args.Total += line.Total()
+		args.Currency = line.Product.Currency
+	}
+	return args
+}
+
+func (t *templates) RenderDelivery(w io.Writer, args *DeliveryArgs) {
+	t.renderBase(w, &baseArgs{Body: t.ExecuteTemplateString(t.Lists, "bodydelivery", args), Title: "Order " + args.List.Title, Description: GetDescription("")})
+}
+
+type DeliveryOrdersArgs struct {
+	ListId int64
+	// The most recent first
+	Orders []delivery.Order
+}
+
+// Open tells whether an order of the list is still to be delivered.
+func (a *DeliveryOrdersArgs) Open() bool {
+	for _, order := range a.Orders {
+		if !order.Status.IsFinal() {
+			return true
+		}
+	}
+	return false
+}
+
+func (t *templates) RenderDeliveryOrders(w io.Writer, args *DeliveryOrdersArgs) {
+	err := t.List.ExecuteTemplate(w, "deliveryorders", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
 type PasswordRecoveryArgs struct {
 	Token string
 }
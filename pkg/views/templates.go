@@ -7,6 +7,8 @@ import (
 	"time"
 
 	"vilmasoftware.com/colablists/pkg/list"
+	"vilmasoftware.com/colablists/pkg/trip"
+	"vilmasoftware.com/colablists/pkg/units"
 	"vilmasoftware.com/colablists/pkg/user"
 )
 
@@ -20,12 +22,15 @@ type templates struct {
 	Signup      *textTemplate.Template
 	Base        *textTemplate.Template
 	Communities *textTemplate.Template
+	Recipes     *textTemplate.Template
 }
 
 type ListUi struct {
 	*list.List
 	ColaboratorsOnline []*UserUi
 	LastUsed           time.Time
+	// Shopping trip in progress, if any
+	Trip *TripUi
 	// Try not to use this
 	// focusMap map[int64]map[int]int
 }
@@ -36,6 +41,61 @@ type UserUi struct {
 	//*Action
 }
 
+type TripUi struct {
+	Shopper   *user.User
+	StartedAt time.Time
+	// Who checked each item during the trip, by item id
+	PickedBy map[int64]*user.User
+}
+
+type TripPick struct {
+	Description string
+	User        *user.User
+}
+
+type TripArgs struct {
+	Trip   *TripUi
+	Picked int
+	Total  int
+	Picks  []TripPick
+	// Summary of the trip that just ended, if any
+	Summary *trip.Trip
+}
+
+func (t *TripArgs) Percent() int {
+	if t.Total == 0 {
+		return 0
+	}
+	return t.Picked * 100 / t.Total
+}
+
+func NewTripArgs(l *ListUi) *TripArgs {
+	args := &TripArgs{Trip: l.Trip, Picks: []TripPick{}}
+	for _, group := range l.Groups {
+		for _, item := range group.Items {
+			args.Total++
+			if item.Checked == 0 {
+				continue
+			}
+			args.Picked++
+			if l.Trip == nil {
+				continue
+			}
+			if picker, ok := l.Trip.PickedBy[item.Id]; ok {
+				args.Picks = append(args.Picks, TripPick{Description: item.Description, User: picker})
+			}
+		}
+	}
+	return args
+}
+
+func (t *templates) RenderTrip(w io.Writer, args *TripArgs) {
+	err := t.List.ExecuteTemplate(w, "trip", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
 type IndexArgs struct {
 	Title       string
 	Description string
@@ -49,7 +109,7 @@ type ColaboratorsListArgs = []Colaborator
 func NewListUi(list *list.List, user *user.User) *ListUi {
 	return &ListUi{
 		List:               list,
-		ColaboratorsOnline: []*UserUi{{User: user, Color: "#18d825"}},
+		ColaboratorsOnline: []*UserUi{{User: user, Color: user.PreferredColor()}},
 		LastUsed:           time.Now(),
 	}
 }
@@ -79,6 +139,76 @@ func (t *templates) RenderItemQuantity(w io.Writer, args ItemArgs) {
 	}
 }
 
+func (t *templates) RenderItemUnit(w io.Writer, args ItemArgs) {
+	err := t.List.ExecuteTemplate(w, "itemunit", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderGroupTotal(w io.Writer, args GroupArgs) {
+	err := t.List.ExecuteTemplate(w, "grouptotal", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderItemPrice(w io.Writer, args ItemArgs) {
+	err := t.List.ExecuteTemplate(w, "itemprice", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderItemNote(w io.Writer, args ItemArgs) {
+	err := t.List.ExecuteTemplate(w, "itemnote", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderItemAttachments(w io.Writer, args ItemArgs) {
+	err := t.List.ExecuteTemplate(w, "itemattachments", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderItemAssignee(w io.Writer, args ItemArgs) {
+	err := t.List.ExecuteTemplate(w, "itemassignee", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderGroups(w io.Writer, args *ListUi) {
+	err := t.List.ExecuteTemplate(w, "groups", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderSuggestions(w io.Writer, suggestions []list.Suggestion) {
+	err := t.List.ExecuteTemplate(w, "suggestions", suggestions)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderOccurrence(w io.Writer, args *ListUi) {
+	err := t.List.ExecuteTemplate(w, "occurrence", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func (t *templates) RenderBudget(w io.Writer, args *ListUi) {
+	err := t.List.ExecuteTemplate(w, "budget", args)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (t *templates) RenderItem(w io.Writer, args ItemArgs) {
 	err := t.List.ExecuteTemplate(w, "item", args)
 	if err != nil {
@@ -110,12 +240,30 @@ type IndexedItem struct {
 	Color      string     `json:"color"`
 	AvatarUrl  *string    `json:"avatarUrl"`
 	HxSwapOob  string
+	// Members of the list the item can be assigned to
+	Assignees []user.User
 }
+
+func (i IndexedItem) Assignee() *user.User {
+	if i.Item.AssigneeId == nil {
+		return nil
+	}
+	for idx := range i.Assignees {
+		if i.Assignees[idx].Id == *i.Item.AssigneeId {
+			return &i.Assignees[idx]
+		}
+	}
+	return nil
+}
+
 type IndexedGroup struct {
 	GroupIndex int64       `json:"groupIndex"`
 	Group      *list.Group `json:"group"`
 	Id         string
 	HxSwapOob  string
+	// Currency of the list, used for items without their own
+	Currency  string
+	Assignees []user.User
 }
 
 func NewGroupIndex(groupIndex int64, group *list.Group, hxSwapOob string) *IndexedGroup {
@@ -133,13 +281,26 @@ func newTemplates() *templates {
 	templates.Auth = textTemplate.Must(textTemplate.ParseFiles("./templates/pages/auth.html", "./templates/pages/_base.html"))
 	templates.Lists = textTemplate.Must(textTemplate.ParseFiles("./templates/pages/lists.html", "./templates/pages/_base.html"))
 	templates.Communities = textTemplate.Must(textTemplate.ParseFiles("./templates/pages/communities.html", "./templates/pages/_base.html"))
+	templates.Recipes = textTemplate.Must(textTemplate.ParseFiles("./templates/pages/recipes.html", "./templates/pages/_base.html"))
 	templates.List = textTemplate.Must(textTemplate.New("list.html").Funcs(textTemplate.FuncMap{
-		"indexeditem": func(groupIndex int64, itemIndex int64, item *list.Item, color string) *IndexedItem {
-			return NewIndexedItem(groupIndex, itemIndex, item, color, nil, "")
+		"indexeditem": func(groupIndex int64, itemIndex int64, item *list.Item, color string, assignees []user.User) *IndexedItem {
+			i := NewIndexedItem(groupIndex, itemIndex, item, color, nil, "")
+			i.Assignees = assignees
+			return i
+		},
+		"indexedgroup": func(groupIndex int64, group *list.Group, currency string, assignees []user.User) *IndexedGroup {
+			g := NewGroupIndex(groupIndex, group, "")
+			g.Currency = currency
+			g.Assignees = assignees
+			return g
+		},
+		"tripargs": func(l ListUi) *TripArgs {
+			return NewTripArgs(&l)
 		},
-		"indexedgroup": func(groupIndex int64, group *list.Group) *IndexedGroup {
-			return NewGroupIndex(groupIndex, group, "")
+		"units": func() []units.Unit {
+			return units.Catalogue
 		},
+		"money": list.FormatMoney,
 	}).ParseFiles("./templates/pages/list.html", "./templates/pages/_base.html"))
 	return templates
 }